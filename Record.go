@@ -49,6 +49,14 @@ func (o *recordImplementation) IsSoftDeleted() bool {
 	return o.SoftDeletedAtCarbon().IsPast()
 }
 
+func (o *recordImplementation) IsExpired() bool {
+	if o.ExpiresAt() == "" {
+		return false
+	}
+
+	return o.ExpiresAtCarbon().IsPast()
+}
+
 // ============================================================================
 // == GETTERS AND SETTERS
 // ============================================================================
@@ -65,6 +73,18 @@ func (o *recordImplementation) SetCreatedAt(createdAt string) {
 	o.Set(COLUMN_CREATED_AT, createdAt)
 }
 
+func (o *recordImplementation) ExpiresAt() string {
+	return o.Get(COLUMN_EXPIRES_AT)
+}
+
+func (o *recordImplementation) ExpiresAtCarbon() *carbon.Carbon {
+	return carbon.Parse(o.ExpiresAt(), carbon.UTC)
+}
+
+func (o *recordImplementation) SetExpiresAt(expiresAt string) {
+	o.Set(COLUMN_EXPIRES_AT, expiresAt)
+}
+
 func (o *recordImplementation) Type() string {
 	return o.Get(COLUMN_RECORD_TYPE)
 }
@@ -230,3 +250,19 @@ func (o *recordImplementation) UpdatedAtCarbon() *carbon.Carbon {
 func (o *recordImplementation) SetUpdatedAt(updatedAt string) {
 	o.Set(COLUMN_UPDATED_AT, updatedAt)
 }
+
+func (o *recordImplementation) Version() int {
+	return cast.ToInt(o.Get(COLUMN_VERSION))
+}
+
+func (o *recordImplementation) SetVersion(version int) {
+	o.Set(COLUMN_VERSION, cast.ToString(version))
+}
+
+func (o *recordImplementation) PayloadVersion() int {
+	return cast.ToInt(o.Get(COLUMN_PAYLOAD_VERSION))
+}
+
+func (o *recordImplementation) SetPayloadVersion(payloadVersion int) {
+	o.Set(COLUMN_PAYLOAD_VERSION, cast.ToString(payloadVersion))
+}
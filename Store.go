@@ -4,10 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/dromara/carbon/v2"
@@ -22,12 +27,28 @@ import (
 
 // Store defines a session store
 type storeImplementation struct {
-	tableName          string
-	db                 *sql.DB
-	dbDriverName       string
-	automigrateEnabled bool
-	debugEnabled       bool
-	logger             *slog.Logger
+	tableName                string
+	db                       *sql.DB
+	tx                       *sql.Tx
+	dbDriverName             string
+	timeoutSeconds           int64
+	automigrateEnabled       bool
+	versioningEnabled        bool
+	expiryEnabled            bool
+	expirySoftDelete         bool
+	historyEnabled           bool
+	changesEnabled           bool
+	payloadVersioningEnabled bool
+	debugEnabled             bool
+	logger                   *slog.Logger
+	retention                *retentionPolicies
+	hooks                    *hookRegistry
+	subscriptions            *subscriptionRegistry
+	schemas                  *schemaRegistry
+
+	// pendingEvents are the change events of the transaction the store
+	// started, sent to the subscribers once it is committed
+	pendingEvents *[]ChangeEvent
 }
 
 // ============================================================================
@@ -43,6 +64,33 @@ type NewStoreOptions struct {
 	AutomigrateEnabled bool
 	DebugEnabled       bool
 	Logger             *slog.Logger
+
+	// VersioningEnabled adds a version column, used for optimistic
+	// concurrency control by RecordUpdate
+	VersioningEnabled bool
+
+	// ExpiryEnabled adds an expires_at column. Expired records are
+	// excluded from the queries, unless SetExpiredIncluded is used
+	ExpiryEnabled bool
+
+	// ExpirySweepSoftDelete makes ExpireSweep soft delete the expired
+	// records, instead of deleting them
+	ExpirySweepSoftDelete bool
+
+	// HistoryEnabled writes the previous state of a record to the
+	// <table>_history table on every update, soft delete and delete
+	HistoryEnabled bool
+
+	// ChangesEnabled writes every create, update, soft delete and delete
+	// to the <table>_changes table, in the same transaction, to be read
+	// with ChangesSince
+	ChangesEnabled bool
+
+	// PayloadVersioningEnabled adds a payload_version column. The payloads
+	// read are migrated to the latest version of their record type, with
+	// the migrations registered by RegisterPayloadMigration. AutoMigrate
+	// adds the column to an existing table
+	PayloadVersioningEnabled bool
 }
 
 // ============================================================================
@@ -52,12 +100,23 @@ type NewStoreOptions struct {
 // NewStore creates a new session store
 func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 	store := &storeImplementation{
-		tableName:          opts.TableName,
-		automigrateEnabled: opts.AutomigrateEnabled,
-		db:                 opts.DB,
-		dbDriverName:       opts.DbDriverName,
-		debugEnabled:       opts.DebugEnabled,
-		logger:             opts.Logger,
+		tableName:                opts.TableName,
+		automigrateEnabled:       opts.AutomigrateEnabled,
+		versioningEnabled:        opts.VersioningEnabled,
+		expiryEnabled:            opts.ExpiryEnabled,
+		expirySoftDelete:         opts.ExpirySweepSoftDelete,
+		historyEnabled:           opts.HistoryEnabled,
+		changesEnabled:           opts.ChangesEnabled,
+		payloadVersioningEnabled: opts.PayloadVersioningEnabled,
+		db:                       opts.DB,
+		dbDriverName:             opts.DbDriverName,
+		timeoutSeconds:           opts.TimeoutSeconds,
+		debugEnabled:             opts.DebugEnabled,
+		logger:                   opts.Logger,
+		retention:                &retentionPolicies{policies: map[string]time.Duration{}},
+		hooks:                    &hookRegistry{hooks: map[string][]registeredHook{}},
+		subscriptions:            &subscriptionRegistry{subscriptions: map[*subscription]struct{}{}},
+		schemas:                  &schemaRegistry{schemas: map[string]*jsonSchema{}},
 	}
 
 	if store.tableName == "" {
@@ -77,25 +136,126 @@ func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 	}
 
 	if store.automigrateEnabled {
-		store.AutoMigrate()
+		if err := store.AutoMigrate(context.Background()); err != nil {
+			return nil, err
+		}
 	}
 
 	return store, nil
 }
 
 // AutoMigrate migrates the tables
-func (st *storeImplementation) AutoMigrate() error {
-	sql := st.SqlCreateTable()
+func (st *storeImplementation) AutoMigrate(ctx context.Context) error {
+	sqls := []string{st.SqlCreateTable()}
 
-	if st.debugEnabled {
-		log.Println(sql)
+	if st.historyEnabled {
+		sqls = append(sqls, st.SqlCreateHistoryTable())
+
+		indexExists, err := st.historyIndexExists(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !indexExists {
+			sqls = append(sqls, st.SqlCreateHistoryIndex())
+		}
+	}
+
+	if st.changesEnabled {
+		sqls = append(sqls,
+			st.SqlCreateChangesTable(),
+			st.SqlCreateChangesSequenceTable(),
+			st.SqlCreateCheckpointsTable())
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	for _, sql := range sqls {
+		if st.debugEnabled {
+			log.Println(sql)
+		}
+
+		_, err := database.Execute(qctx, sql)
+		if err != nil {
+			return err
+		}
+	}
+
+	tableNames := []string{st.tableName}
+	if st.historyEnabled {
+		tableNames = append(tableNames, st.historyTableName())
+	}
+
+	for _, tableName := range tableNames {
+		if err := st.tableColumnsAdd(qctx, tableName); err != nil {
+			return err
+		}
+	}
+
+	if st.changesEnabled {
+		return st.changeSequenceInit(qctx)
+	}
+
+	return nil
+}
+
+// tableColumnsAdd adds the columns of an option enabled after the table
+// was created, i.e. payload_version. The columns are added as nullable, so
+// the existing rows hold NULL. The version column cannot be, a NULL version
+// would conflict with every update, and must be added by hand
+func (st *storeImplementation) tableColumnsAdd(ctx context.Context, tableName string) error {
+	sqlStr, _, err := goqu.Dialect(st.dbDriverName).
+		From(tableName).
+		Where(goqu.L("1 = 0")).
+		ToSQL()
+
+	if err != nil {
+		return err
 	}
 
-	_, err := st.db.Exec(sql)
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	rows, err := database.Query(qctx, sqlStr)
+
 	if err != nil {
 		return err
 	}
 
+	existing, err := rows.Columns()
+	rows.Close()
+
+	if err != nil {
+		return err
+	}
+
+	for _, column := range st.sqlRecordColumns() {
+		if slices.ContainsFunc(existing, func(name string) bool { return strings.EqualFold(name, column.Name) }) {
+			continue
+		}
+
+		if column.Name != COLUMN_EXPIRES_AT && column.Name != COLUMN_PAYLOAD_VERSION {
+			return errors.New("column " + column.Name + " is missing from table " + tableName + ", it must be added before enabling its option")
+		}
+
+		column.Nullable = true
+
+		sqlStr, err := sb.NewBuilder(st.dbDriverName).TableColumnAdd(tableName, column)
+
+		if err != nil {
+			return err
+		}
+
+		if st.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		if _, err := database.Execute(qctx, sqlStr); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -170,7 +330,7 @@ func (st *storeImplementation) EnableDebug(debugEnabled bool) {
 // }
 
 // RecordUpdate updates a record
-// func (st *storeImplementation) RecordUpdate(record RecordInterface) error {
+// func (st *storeImplementation) RecordUpdate(ctx context.Context, record RecordInterface) error {
 // 	fields := map[string]interface{}{}
 // 	fields[COLUMN_PAYLOAD] = record.Payload()
 // 	fields[COLUMN_UPDATED_AT] = time.Now()
@@ -202,14 +362,14 @@ func (st *storeImplementation) EnableDebug(debugEnabled bool) {
 // }
 
 // RecordCount counts the number of records that match the query
-func (st *storeImplementation) RecordCount(options RecordQueryInterface) (int64, error) {
+func (st *storeImplementation) RecordCount(ctx context.Context, options RecordQueryInterface) (int64, error) {
 	if st.db == nil {
 		return 0, errors.New("database is not initialized")
 	}
 
 	options.SetCountOnly(true)
 
-	q, _, err := options.ToSelectDataset(st.dbDriverName, st.tableName)
+	q, _, err := st.toSelectDataset(options)
 
 	if err != nil {
 		return -1, err
@@ -229,7 +389,10 @@ func (st *storeImplementation) RecordCount(options RecordQueryInterface) (int64,
 		log.Println(sqlStr)
 	}
 
-	mapped, err := database.SelectToMapString(database.Context(context.Background(), st.db), sqlStr, sqlParams...)
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	mapped, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
 	if err != nil {
 		return -1, err
 	}
@@ -250,7 +413,7 @@ func (st *storeImplementation) RecordCount(options RecordQueryInterface) (int64,
 }
 
 // RecordCreate creates a new record
-func (st *storeImplementation) RecordCreate(record RecordInterface) error {
+func (st *storeImplementation) RecordCreate(ctx context.Context, record RecordInterface) error {
 	if st.db == nil {
 		return errors.New("database is not initialized")
 	}
@@ -262,8 +425,28 @@ func (st *storeImplementation) RecordCreate(record RecordInterface) error {
 	record.SetCreatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
 	record.SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
 
+	if st.versioningEnabled && record.Version() < 1 {
+		record.SetVersion(1)
+	}
+
+	if st.payloadVersioningEnabled && record.PayloadVersion() < 1 {
+		record.SetPayloadVersion(LatestPayloadVersion(record.Type()))
+	}
+
+	if err := st.hookRun(ctx, hookBeforeCreate, record, record.DataChanged()); err != nil {
+		return err
+	}
+
+	if err := st.ValidatePayload(record.Type(), record.Payload()); err != nil {
+		return err
+	}
+
 	data := record.Data()
 
+	if err := st.recordColumnsCheck(data); err != nil {
+		return err
+	}
+
 	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
 		Insert(st.tableName).
 		Prepared(true).
@@ -278,7 +461,22 @@ func (st *storeImplementation) RecordCreate(record RecordInterface) error {
 		st.logger.Debug("Record create query", "query", sqlStr, "params", sqlParams)
 	}
 
-	_, err = database.Execute(database.Context(context.Background(), st.db), sqlStr, sqlParams...)
+	changed := maps.Clone(record.DataChanged())
+
+	err = st.executeWrite(ctx, func(txStore *storeImplementation) error {
+		qctx, cancel := txStore.toQuerableContext(ctx)
+		defer cancel()
+
+		if _, err := database.Execute(qctx, sqlStr, sqlParams...); err != nil {
+			return err
+		}
+
+		return txStore.changeLog(ctx, CHANGE_OPERATION_CREATE, changeEntry{
+			recordID:   record.ID(),
+			recordType: record.Type(),
+			changed:    changed,
+		})
+	})
 
 	if err != nil {
 		return err
@@ -286,20 +484,20 @@ func (st *storeImplementation) RecordCreate(record RecordInterface) error {
 
 	record.MarkAsNotDirty()
 
-	return nil
+	return st.hookRun(ctx, hookAfterCreate, record, changed)
 }
 
 // RecordDelete permanently deletes a record
-func (st *storeImplementation) RecordDelete(record RecordInterface) error {
+func (st *storeImplementation) RecordDelete(ctx context.Context, record RecordInterface) error {
 	if record == nil {
 		return errors.New("record is nil")
 	}
 
-	return st.RecordDeleteByID(record.ID())
+	return st.RecordDeleteByID(ctx, record.ID())
 }
 
 // RecordDeleteByID permanently deletes a record by ID
-func (st *storeImplementation) RecordDeleteByID(id string) error {
+func (st *storeImplementation) RecordDeleteByID(ctx context.Context, id string) error {
 	if st.db == nil {
 		return errors.New("database is not initialized")
 	}
@@ -322,16 +520,61 @@ func (st *storeImplementation) RecordDeleteByID(id string) error {
 		st.logger.Debug("Incident delete query", "query", sqlStr, "params", sqlParams)
 	}
 
-	_, err = database.Execute(database.Context(context.Background(), st.db), sqlStr, sqlParams...)
+	// the record is only loaded for the hooks
+	var record RecordInterface
+
+	if st.hookExists(hookBeforeDelete) || st.hookExists(hookAfterDelete) {
+		existing, exists, err := st.recordExisting(ctx, id)
+
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			record = st.recordFromExistingData(existing)
+		}
+	}
+
+	if record != nil {
+		if err := st.hookRun(ctx, hookBeforeDelete, record, map[string]string{}); err != nil {
+			return err
+		}
+	}
+
+	err = st.executeWrite(ctx, func(txStore *storeImplementation) error {
+		if err := txStore.historySnapshot(ctx, HISTORY_OPERATION_DELETE, goqu.C(COLUMN_ID).Eq(id)); err != nil {
+			return err
+		}
+
+		changes, err := txStore.changeEntriesWhere(ctx, nil, goqu.C(COLUMN_ID).Eq(id))
+		if err != nil {
+			return err
+		}
+
+		qctx, cancel := txStore.toQuerableContext(ctx)
+		defer cancel()
+
+		_, err = database.Execute(qctx, sqlStr, sqlParams...)
+		if err != nil {
+			return err
+		}
+
+		return txStore.changeLog(ctx, CHANGE_OPERATION_DELETE, changes...)
+	})
+
 	if err != nil {
 		return err
 	}
 
+	if record != nil {
+		return st.hookRun(ctx, hookAfterDelete, record, map[string]string{})
+	}
+
 	return nil
 }
 
 // RecordFindByID returns a record by ID
-func (st *storeImplementation) RecordFindByID(id string) (record RecordInterface, err error) {
+func (st *storeImplementation) RecordFindByID(ctx context.Context, id string) (record RecordInterface, err error) {
 	if st.db == nil {
 		return nil, errors.New("database is not initialized")
 	}
@@ -340,7 +583,7 @@ func (st *storeImplementation) RecordFindByID(id string) (record RecordInterface
 		return nil, errors.New("record id is empty")
 	}
 
-	list, err := st.RecordList(RecordQuery().
+	list, err := st.RecordList(ctx, RecordQuery().
 		SetID(id).
 		SetLimit(1))
 
@@ -356,12 +599,12 @@ func (st *storeImplementation) RecordFindByID(id string) (record RecordInterface
 }
 
 // RecordList returns a list of records
-func (st *storeImplementation) RecordList(query RecordQueryInterface) ([]RecordInterface, error) {
+func (st *storeImplementation) RecordList(ctx context.Context, query RecordQueryInterface) ([]RecordInterface, error) {
 	if st.db == nil {
 		return nil, errors.New("database is not initialized")
 	}
 
-	q, columns, err := query.ToSelectDataset(st.dbDriverName, st.tableName)
+	q, columns, err := st.toSelectDataset(query)
 
 	if err != nil {
 		return []RecordInterface{}, err
@@ -377,7 +620,10 @@ func (st *storeImplementation) RecordList(query RecordQueryInterface) ([]RecordI
 		log.Println(sqlStr)
 	}
 
-	modelMaps, err := database.SelectToMapString(database.Context(context.Background(), st.db), sqlStr, sqlParams...)
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	modelMaps, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
 
 	if err != nil {
 		return []RecordInterface{}, err
@@ -386,30 +632,130 @@ func (st *storeImplementation) RecordList(query RecordQueryInterface) ([]RecordI
 	list := []RecordInterface{}
 
 	lo.ForEach(modelMaps, func(modelMap map[string]string, index int) {
-		model := NewRecordFromExistingData(modelMap)
+		model := st.recordFromExistingData(modelMap)
 		list = append(list, model)
 	})
 
 	return list, nil
 }
 
-func (store *storeImplementation) RecordSoftDelete(record RecordInterface) error {
+// RecordListPage returns a page of records using keyset pagination.
+// The NextCursor of the page is passed to SetCursor to fetch the next page.
+func (st *storeImplementation) RecordListPage(ctx context.Context, query RecordQueryInterface) (RecordPage, error) {
+	if query == nil {
+		return RecordPage{}, errors.New("query is nil")
+	}
+
+	if !query.IsCursorSet() {
+		query.SetCursor("") // first page
+	}
+
+	limit := 10
+	if query.IsLimitSet() && query.GetLimit() > 0 {
+		limit = query.GetLimit()
+	}
+
+	// one more record is fetched, to find out if there is a next page
+	query.SetLimit(limit + 1)
+	list, err := st.RecordList(ctx, query)
+	query.SetLimit(limit)
+
+	if err != nil {
+		return RecordPage{}, err
+	}
+
+	if len(list) <= limit {
+		return RecordPage{Records: list}, nil
+	}
+
+	list = list[:limit]
+
+	nextCursor, err := query.NextCursor(list[len(list)-1])
+
+	if err != nil {
+		return RecordPage{}, err
+	}
+
+	return RecordPage{Records: list, NextCursor: nextCursor}, nil
+}
+
+// RecordFindByIDWithDeleted returns a record by ID, including a soft deleted
+// one, expired or not
+func (st *storeImplementation) RecordFindByIDWithDeleted(ctx context.Context, id string) (record RecordInterface, err error) {
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if id == "" {
+		return nil, errors.New("record id is empty")
+	}
+
+	list, err := st.RecordList(ctx, RecordQuery().
+		SetID(id).
+		SetSoftDeletedIncluded(true).
+		SetExpiredIncluded(true).
+		SetLimit(1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) > 0 {
+		return list[0], nil
+	}
+
+	return nil, nil
+}
+
+// RecordRestore restores a soft deleted record
+func (store *storeImplementation) RecordRestore(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+
+	record.SetSoftDeletedAt(sb.MAX_DATETIME)
+
+	return store.RecordUpdate(ctx, record)
+}
+
+// RecordRestoreByID restores a soft deleted record by ID
+func (store *storeImplementation) RecordRestoreByID(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("record id is empty")
+	}
+
+	record, err := store.RecordFindByIDWithDeleted(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	// compared as the queries do, so a record soft deleted this second counts
+	if record == nil || record.SoftDeletedAtCarbon().Gt(carbon.Now(carbon.UTC)) {
+		return nil // Record does not exist, or is not soft deleted
+	}
+
+	return store.RecordRestore(ctx, record)
+}
+
+// RecordSoftDelete soft deletes a record
+func (store *storeImplementation) RecordSoftDelete(ctx context.Context, record RecordInterface) error {
 	if record == nil {
 		return errors.New("record is nil")
 	}
 
 	record.SetSoftDeletedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
 
-	return store.RecordUpdate(record)
+	return store.RecordUpdate(ctx, record)
 }
 
 // RecordSoftDeleteByID soft deletes a record by ID
-func (store *storeImplementation) RecordSoftDeleteByID(id string) error {
+func (store *storeImplementation) RecordSoftDeleteByID(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("record id is empty")
 	}
 
-	record, err := store.RecordFindByID(id)
+	record, err := store.RecordFindByID(ctx, id)
 
 	if err != nil {
 		return err
@@ -419,11 +765,15 @@ func (store *storeImplementation) RecordSoftDeleteByID(id string) error {
 		return nil // Record does not exist, or is already soft deleted
 	}
 
-	return store.RecordSoftDelete(record)
+	return store.RecordSoftDelete(ctx, record)
 }
 
 // RecordUpdate updates a record
-func (st *storeImplementation) RecordUpdate(record RecordInterface) error {
+//
+// When versioning is enabled, the update only succeeds if the version of
+// the record in the database is still the one the record was loaded with.
+// Otherwise ErrVersionConflict is returned.
+func (st *storeImplementation) RecordUpdate(ctx context.Context, record RecordInterface) error {
 	if st.db == nil {
 		return errors.New("database is not initialized")
 	}
@@ -438,19 +788,49 @@ func (st *storeImplementation) RecordUpdate(record RecordInterface) error {
 
 	record.SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString())
 
-	dataChanged := record.DataChanged()
+	if err := st.hookRun(ctx, hookBeforeUpdate, record, record.DataChanged()); err != nil {
+		return err
+	}
+
+	if err := st.schemaValidateRecord(record, record.DataChanged()); err != nil {
+		return err
+	}
 
-	delete(dataChanged, COLUMN_ID) // ID is not updateable
+	dataChanged := maps.Clone(record.DataChanged())
+
+	delete(dataChanged, COLUMN_ID)      // ID is not updateable
+	delete(dataChanged, COLUMN_VERSION) // version is managed by the store
 
 	if len(dataChanged) < 1 {
 		return nil
 	}
 
+	if err := st.recordColumnsCheck(dataChanged); err != nil {
+		return err
+	}
+
+	fields := map[string]any{}
+	for column, value := range dataChanged {
+		if value == "" && isDateTimeColumn(column) {
+			fields[column] = nil // i.e. an expiry removed, as '' is not a datetime
+			continue
+		}
+
+		fields[column] = value
+	}
+
+	where := []goqu.Expression{goqu.C(COLUMN_ID).Eq(record.ID())}
+
+	if st.versioningEnabled {
+		fields[COLUMN_VERSION] = record.Version() + 1
+		where = append(where, goqu.C(COLUMN_VERSION).Eq(record.Version()))
+	}
+
 	sqlStr, params, errSql := goqu.Dialect(st.dbDriverName).
 		Update(st.tableName).
 		Prepared(true).
-		Set(dataChanged).
-		Where(goqu.C(COLUMN_ID).Eq(record.ID())).
+		Set(fields).
+		Where(where...).
 		ToSQL()
 
 	if errSql != nil {
@@ -461,9 +841,151 @@ func (st *storeImplementation) RecordUpdate(record RecordInterface) error {
 		log.Println(sqlStr)
 	}
 
-	_, err := st.db.Exec(sqlStr, params...)
+	operation := HISTORY_OPERATION_UPDATE
+	changeOperation := CHANGE_OPERATION_UPDATE
+	if softDeletedAt, isChanged := dataChanged[COLUMN_SOFT_DELETED_AT]; isChanged &&
+		softDeletedAt != "" && carbon.Parse(softDeletedAt, carbon.UTC).Lte(carbon.Now(carbon.UTC)) {
+		operation = HISTORY_OPERATION_SOFT_DELETE
+		changeOperation = CHANGE_OPERATION_SOFT_DELETE
+	} else if isChanged && softDeletedAt != "" {
+		changeOperation = CHANGE_OPERATION_RESTORE
+	}
+
+	err := st.executeWrite(ctx, func(txStore *storeImplementation) error {
+		if err := txStore.historySnapshot(ctx, operation, where...); err != nil {
+			return err
+		}
+
+		qctx, cancel := txStore.toQuerableContext(ctx)
+		defer cancel()
+
+		result, err := database.Execute(qctx, sqlStr, params...)
+
+		if err != nil {
+			return err
+		}
+
+		if st.versioningEnabled {
+			rowsAffected, err := result.RowsAffected()
+
+			if err != nil {
+				return err
+			}
+
+			if rowsAffected < 1 {
+				return fmt.Errorf("%w: record %s at version %d", ErrVersionConflict, record.ID(), record.Version())
+			}
+		}
+
+		return txStore.changeLog(ctx, changeOperation, changeEntry{
+			recordID:   record.ID(),
+			recordType: record.Type(),
+			changed:    dataChanged,
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if st.versioningEnabled {
+		record.SetVersion(record.Version() + 1)
+	}
 
 	record.MarkAsNotDirty()
 
-	return err
+	return st.hookRun(ctx, hookAfterUpdate, record, dataChanged)
+}
+
+// recordColumnsCheck returns an error if the data has a column the table
+// does not have, as an option it belongs to is not enabled
+func (st *storeImplementation) recordColumnsCheck(data map[string]string) error {
+	columns := st.recordColumnNames()
+
+	for column := range data {
+		if slices.Contains(columns, column) {
+			continue
+		}
+
+		switch column {
+		case COLUMN_EXPIRES_AT:
+			return errors.New("column " + column + " requires expiry to be enabled")
+		case COLUMN_VERSION:
+			return errors.New("column " + column + " requires versioning to be enabled")
+		case COLUMN_PAYLOAD_VERSION:
+			return errors.New("column " + column + " requires payload versioning to be enabled")
+		default:
+			return errors.New("column " + column + " is not a column of the store")
+		}
+	}
+
+	return nil
+}
+
+// toSelectDataset builds the select dataset of the query, adding the
+// filters which depend on the store options
+func (st *storeImplementation) toSelectDataset(query RecordQueryInterface) (*goqu.SelectDataset, []any, error) {
+	if query == nil {
+		return nil, []any{}, errors.New("query is nil")
+	}
+
+	q, columns, err := query.ToSelectDataset(st.dbDriverName, st.tableName)
+
+	if err != nil {
+		return nil, []any{}, err
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	if query.IsAsOfSet() {
+		if !st.historyEnabled {
+			return nil, []any{}, errors.New("as of queries require history to be enabled")
+		}
+
+		now = query.GetAsOf()
+
+		q = q.From(st.asOfSource(now).As(st.tableName)).
+			Where(goqu.C(COLUMN_CREATED_AT).Lte(now))
+	}
+
+	if st.expiryEnabled && !query.IsExpiredIncluded() {
+		q = q.Where(goqu.Or(
+			goqu.C(COLUMN_EXPIRES_AT).IsNull(),
+			goqu.C(COLUMN_EXPIRES_AT).Gt(now),
+		))
+	}
+
+	return q, columns, nil
+}
+
+// toQuerableContext converts the context to a queryable context, reusing
+// the transaction the store is bound to, or the transaction or connection
+// already attached to the context (if any), and applies the store's
+// default timeout when the context has no deadline
+func (st *storeImplementation) toQuerableContext(ctx context.Context) (database.QueryableContext, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var qctx database.QueryableContext
+
+	if st.tx != nil {
+		qctx = database.Context(ctx, st.tx)
+	} else if database.IsQueryableContext(ctx) {
+		qctx = ctx.(database.QueryableContext)
+	} else {
+		qctx = database.Context(ctx, st.db)
+	}
+
+	if st.timeoutSeconds <= 0 {
+		return qctx, func() {}
+	}
+
+	if _, hasDeadline := qctx.Deadline(); hasDeadline {
+		return qctx, func() {}
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(qctx.Context, time.Duration(st.timeoutSeconds)*time.Second)
+
+	return database.Context(timeoutCtx, qctx.Queryable()), cancel
 }
@@ -2,9 +2,12 @@
 package customstore_test // Changed package name
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -90,7 +93,7 @@ func TestRecordCreate(t *testing.T) {
 
 	// Use customstore.NewRecord
 	record := customstore.NewRecord("person")
-	err = store.RecordCreate(record)
+	err = store.RecordCreate(context.Background(), record)
 
 	if err != nil {
 		t.Fatalf("Record could not be created: %v", err)
@@ -140,7 +143,7 @@ func TestRecordFindByID(t *testing.T) {
 		t.Fatalf("SetPayloadMap failed: %v", err)
 	}
 
-	err = store.RecordCreate(record)
+	err = store.RecordCreate(context.Background(), record)
 	if err != nil {
 		t.Fatalf("Record could not be created: %v", err)
 	}
@@ -150,7 +153,7 @@ func TestRecordFindByID(t *testing.T) {
 		t.Fatalf("Expected Record ID length 32, but got %d (%s)", len(record.ID()), record.ID())
 	}
 
-	retrievedRecord, errFind := store.RecordFindByID(record.ID())
+	retrievedRecord, errFind := store.RecordFindByID(context.Background(), record.ID())
 	if errFind != nil {
 		t.Fatalf("RecordFindByID failed: %v", errFind)
 	}
@@ -167,7 +170,7 @@ func TestRecordFindByID(t *testing.T) {
 
 	// Test with non-existent ID
 	nonExistentID := uid.HumanUid()
-	retrievedRecord, errFind = store.RecordFindByID(nonExistentID)
+	retrievedRecord, errFind = store.RecordFindByID(context.Background(), nonExistentID)
 	// Expecting NO error when record is not found, just a nil record
 	if errFind != nil {
 		t.Fatalf("RecordFindByID for non-existent ID failed unexpectedly: %v", errFind)
@@ -205,13 +208,13 @@ func TestRecordUpdate(t *testing.T) {
 		t.Fatalf("Initial SetPayloadMap failed: %v", err)
 	}
 
-	err = store.RecordCreate(record)
+	err = store.RecordCreate(context.Background(), record)
 	if err != nil {
 		t.Fatalf("Record could not be created: %v", err)
 	}
 	initialUpdatedAt := record.UpdatedAt() // Store initial timestamp
 
-	retrievedRecord, errFind := store.RecordFindByID(record.ID())
+	retrievedRecord, errFind := store.RecordFindByID(context.Background(), record.ID())
 	if errFind != nil {
 		t.Fatalf("RecordFindByID failed: %v", errFind)
 	}
@@ -239,13 +242,13 @@ func TestRecordUpdate(t *testing.T) {
 		t.Fatalf("Update SetPayloadMap failed: %v", err)
 	}
 
-	err = store.RecordUpdate(retrievedRecord)
+	err = store.RecordUpdate(context.Background(), retrievedRecord)
 	if err != nil {
 		t.Fatalf("Record could not be updated: %v", err)
 	}
 
 	// Retrieve again to verify update
-	retrievedRecord2, errFind := store.RecordFindByID(record.ID())
+	retrievedRecord2, errFind := store.RecordFindByID(context.Background(), record.ID())
 	if errFind != nil {
 		t.Fatalf("RecordFindByID after update failed: %v", errFind)
 	}
@@ -286,19 +289,19 @@ func TestRecordDelete(t *testing.T) {
 	}
 
 	record := customstore.NewRecord("person")
-	err = store.RecordCreate(record)
+	err = store.RecordCreate(context.Background(), record)
 	if err != nil {
 		t.Fatalf("Record could not be created: %v", err)
 	}
 
 	// Delete using the record object
-	err = store.RecordDelete(record)
+	err = store.RecordDelete(context.Background(), record)
 	if err != nil {
 		t.Fatalf("RecordDelete failed: %v", err)
 	}
 
 	// Verify it's gone
-	retrievedRecord, errFind := store.RecordFindByID(record.ID())
+	retrievedRecord, errFind := store.RecordFindByID(context.Background(), record.ID())
 	if errFind != nil {
 		t.Fatalf("RecordFindByID after delete failed unexpectedly: %v", errFind)
 	}
@@ -307,7 +310,7 @@ func TestRecordDelete(t *testing.T) {
 	}
 
 	// Test deleting a non-existent ID (should not error)
-	err = store.RecordDeleteByID(uid.HumanUid())
+	err = store.RecordDeleteByID(context.Background(), uid.HumanUid())
 	if err != nil {
 		t.Fatalf("RecordDeleteByID for non-existent ID failed unexpectedly: %v", err)
 	}
@@ -331,19 +334,19 @@ func TestRecordSoftDelete(t *testing.T) {
 	}
 
 	record := customstore.NewRecord("person")
-	err = store.RecordCreate(record)
+	err = store.RecordCreate(context.Background(), record)
 	if err != nil {
 		t.Fatalf("Record could not be created: %v", err)
 	}
 
 	// Soft delete using the record object
-	err = store.RecordSoftDelete(record)
+	err = store.RecordSoftDelete(context.Background(), record)
 	if err != nil {
 		t.Fatalf("RecordSoftDelete failed: %v", err)
 	}
 
 	// Verify it's not found by default find
-	retrievedRecord, errFind := store.RecordFindByID(record.ID())
+	retrievedRecord, errFind := store.RecordFindByID(context.Background(), record.ID())
 	if errFind != nil {
 		t.Fatalf("RecordFindByID after soft delete failed unexpectedly: %v", errFind)
 	}
@@ -352,7 +355,7 @@ func TestRecordSoftDelete(t *testing.T) {
 	}
 
 	// Test soft deleting a non-existent ID (should not error if find returns nil)
-	err = store.RecordSoftDeleteByID(uid.HumanUid())
+	err = store.RecordSoftDeleteByID(context.Background(), uid.HumanUid())
 	if err != nil {
 		t.Fatalf("RecordSoftDeleteByID for non-existent ID failed unexpectedly: %v", err)
 	}
@@ -360,7 +363,7 @@ func TestRecordSoftDelete(t *testing.T) {
 	// Test finding with soft deleted included
 	// Use customstore.RecordQuery
 	query := customstore.RecordQuery().SetSoftDeletedIncluded(true).SetID(record.ID())
-	list, err := store.RecordList(query)
+	list, err := store.RecordList(context.Background(), query)
 	if err != nil {
 		t.Fatalf("RecordList with soft deleted included failed: %v", err)
 	}
@@ -379,6 +382,112 @@ func TestRecordSoftDelete(t *testing.T) {
 	}
 }
 
+func TestRecordRestore(t *testing.T) {
+	db := InitDB("test_data_store_record_restore.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_restore",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person")
+	if err := store.RecordCreate(context.Background(), record); err != nil {
+		t.Fatalf("Record could not be created: %v", err)
+	}
+
+	other := customstore.NewRecord("person")
+	if err := store.RecordCreate(context.Background(), other); err != nil {
+		t.Fatalf("Record could not be created: %v", err)
+	}
+
+	if err := store.RecordSoftDeleteByID(context.Background(), record.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+
+	found, err := store.RecordFindByIDWithDeleted(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByIDWithDeleted failed: %v", err)
+	}
+	if found == nil {
+		t.Fatalf("Expected the soft deleted record to be found, but got nil")
+	}
+
+	trash, err := store.RecordList(context.Background(), customstore.RecordQuery().
+		SetType("person").
+		SetOnlySoftDeleted(true))
+	if err != nil {
+		t.Fatalf("RecordList of soft deleted records failed: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID() != record.ID() {
+		t.Fatalf("Expected only the soft deleted record in the trash, but got %d records", len(trash))
+	}
+
+	if err := store.RecordRestoreByID(context.Background(), record.ID()); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+
+	restored, err := store.RecordFindByID(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID after restore failed: %v", err)
+	}
+	if restored == nil {
+		t.Fatalf("Expected the restored record to be found, but got nil")
+	}
+	if restored.IsSoftDeleted() {
+		t.Fatalf("Expected the restored record not to be soft deleted")
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery().SetOnlySoftDeleted(true))
+	if err != nil {
+		t.Fatalf("RecordCount of soft deleted records failed: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected the trash to be empty after restore, but got %d records", count)
+	}
+}
+
+func TestRecordQuerySoftDeletedIncludedKeepsFilters(t *testing.T) {
+	db := InitDB("test_data_store_record_soft_deleted_included.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_soft_deleted_included",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, recordType := range []string{"person", "person", "company"} {
+		record := customstore.NewRecord(recordType)
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("Record could not be created: %v", err)
+		}
+		if err := store.RecordSoftDelete(context.Background(), record); err != nil {
+			t.Fatalf("RecordSoftDelete failed: %v", err)
+		}
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery().
+		SetType("person").
+		SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("Expected the type filter to be kept, 2 records, but got %d", count)
+	}
+}
+
 func TestRecordList(t *testing.T) {
 	db := InitDB("test_data_store_record_list.db")
 	defer db.Close()
@@ -402,7 +511,7 @@ func TestRecordList(t *testing.T) {
 	if err != nil {
 		t.Fatalf("SetPayloadMap record1 failed: %v", err)
 	}
-	err = store.RecordCreate(record1)
+	err = store.RecordCreate(context.Background(), record1)
 	if err != nil {
 		t.Fatalf("RecordCreate record1 failed: %v", err)
 	}
@@ -412,13 +521,13 @@ func TestRecordList(t *testing.T) {
 	if err != nil {
 		t.Fatalf("SetPayloadMap record2 failed: %v", err)
 	}
-	err = store.RecordCreate(record2)
+	err = store.RecordCreate(context.Background(), record2)
 	if err != nil {
 		t.Fatalf("RecordCreate record2 failed: %v", err)
 	}
 
 	// List all records
-	list, err := store.RecordList(customstore.RecordQuery())
+	list, err := store.RecordList(context.Background(), customstore.RecordQuery())
 	if err != nil {
 		t.Fatalf("RecordList failed: %v", err)
 	}
@@ -473,7 +582,7 @@ func TestRecordCount(t *testing.T) {
 	if err != nil {
 		t.Fatalf("SetPayloadMap record1 failed: %v", err)
 	}
-	err = store.RecordCreate(record1)
+	err = store.RecordCreate(context.Background(), record1)
 	if err != nil {
 		t.Fatalf("RecordCreate record1 failed: %v", err)
 	}
@@ -483,13 +592,13 @@ func TestRecordCount(t *testing.T) {
 	if err != nil {
 		t.Fatalf("SetPayloadMap record2 failed: %v", err)
 	}
-	err = store.RecordCreate(record2)
+	err = store.RecordCreate(context.Background(), record2)
 	if err != nil {
 		t.Fatalf("RecordCreate record2 failed: %v", err)
 	}
 
 	// Count all records
-	count, err := store.RecordCount(customstore.RecordQuery())
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery())
 	if err != nil {
 		t.Fatalf("RecordCount failed: %v", err)
 	}
@@ -522,7 +631,7 @@ func TestRecordQuery(t *testing.T) {
 	if err != nil {
 		t.Fatalf("SetPayloadMap record1 failed: %v", err)
 	}
-	err = store.RecordCreate(record1)
+	err = store.RecordCreate(context.Background(), record1)
 	if err != nil {
 		t.Fatalf("RecordCreate record1 failed: %v", err)
 	}
@@ -532,14 +641,14 @@ func TestRecordQuery(t *testing.T) {
 	if err != nil {
 		t.Fatalf("SetPayloadMap record2 failed: %v", err)
 	}
-	err = store.RecordCreate(record2)
+	err = store.RecordCreate(context.Background(), record2)
 	if err != nil {
 		t.Fatalf("RecordCreate record2 failed: %v", err)
 	}
 
 	// Test with type
 	query := customstore.RecordQuery().SetType("person")
-	list, err := store.RecordList(query)
+	list, err := store.RecordList(context.Background(), query)
 	if err != nil {
 		t.Fatalf("RecordList with type 'person' failed: %v", err)
 	}
@@ -553,7 +662,7 @@ func TestRecordQuery(t *testing.T) {
 
 	// Test with limit
 	query = customstore.RecordQuery().SetLimit(1)
-	list, err = store.RecordList(query)
+	list, err = store.RecordList(context.Background(), query)
 	if err != nil {
 		t.Fatalf("RecordList with limit 1 failed: %v", err)
 	}
@@ -563,7 +672,7 @@ func TestRecordQuery(t *testing.T) {
 
 	// Test with offset (needs limit)
 	query = customstore.RecordQuery().SetOffset(1).SetLimit(1) // Ensure limit is set
-	list, err = store.RecordList(query)
+	list, err = store.RecordList(context.Background(), query)
 	if err != nil {
 		t.Fatalf("RecordList with offset 1 failed: %v", err)
 	}
@@ -573,7 +682,7 @@ func TestRecordQuery(t *testing.T) {
 
 	// Test with order by (use exported constant)
 	query = customstore.RecordQuery().SetOrderBy(customstore.COLUMN_CREATED_AT)
-	list, err = store.RecordList(query)
+	list, err = store.RecordList(context.Background(), query)
 	if err != nil {
 		t.Fatalf("RecordList with order by failed: %v", err)
 	}
@@ -604,7 +713,7 @@ func TestRecordCreateWithEmptyID(t *testing.T) {
 
 	record := customstore.NewRecord("person")
 	record.SetID("") // Explicitly set empty ID
-	err = store.RecordCreate(record)
+	err = store.RecordCreate(context.Background(), record)
 
 	if err == nil {
 		t.Fatalf("Expected error when creating record with empty ID, but got nil")
@@ -635,7 +744,7 @@ func TestRecordUpdateWithEmptyID(t *testing.T) {
 
 	record := customstore.NewRecord("person")
 	// Need to create it first to attempt an update
-	err = store.RecordCreate(record)
+	err = store.RecordCreate(context.Background(), record)
 	if err != nil {
 		t.Fatalf("Setup: RecordCreate failed: %v", err)
 	}
@@ -643,7 +752,7 @@ func TestRecordUpdateWithEmptyID(t *testing.T) {
 	// Now try to update with an empty ID (on a different instance to simulate error)
 	recordToUpdate := customstore.NewRecord("person")
 	recordToUpdate.SetID("")
-	err = store.RecordUpdate(recordToUpdate)
+	err = store.RecordUpdate(context.Background(), recordToUpdate)
 
 	if err == nil {
 		t.Fatalf("Expected error when updating record with empty ID, but got nil")
@@ -671,7 +780,7 @@ func TestRecordDeleteWithEmptyID(t *testing.T) {
 		t.Fatalf("Store is nil after creation without error")
 	}
 
-	err = store.RecordDeleteByID("")
+	err = store.RecordDeleteByID(context.Background(), "")
 
 	if err == nil {
 		t.Fatalf("Expected error when deleting record with empty ID, but got nil")
@@ -699,7 +808,7 @@ func TestRecordFindByIDWithEmptyID(t *testing.T) {
 		t.Fatalf("Store is nil after creation without error")
 	}
 
-	_, err = store.RecordFindByID("")
+	_, err = store.RecordFindByID(context.Background(), "")
 
 	if err == nil {
 		t.Fatalf("Expected error when finding record with empty ID, but got nil")
@@ -727,7 +836,7 @@ func TestRecordSoftDeleteWithEmptyID(t *testing.T) {
 		t.Fatalf("Store is nil after creation without error")
 	}
 
-	err = store.RecordSoftDeleteByID("")
+	err = store.RecordSoftDeleteByID(context.Background(), "")
 
 	if err == nil {
 		t.Fatalf("Expected error when soft deleting record with empty ID, but got nil")
@@ -772,7 +881,7 @@ func TestRecordQueryPayloadSearch(t *testing.T) {
 		if err != nil {
 			t.Fatalf("SetPayloadMap record %d failed: %v", i+1, err)
 		}
-		err = store.RecordCreate(rec)
+		err = store.RecordCreate(context.Background(), rec)
 		if err != nil {
 			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
 		}
@@ -799,7 +908,7 @@ func TestRecordQueryPayloadSearch(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run("Search_"+tc.searchTerm, func(t *testing.T) {
 			query := customstore.RecordQuery().AddPayloadSearch(tc.searchTerm)
-			list, err := store.RecordList(query)
+			list, err := store.RecordList(context.Background(), query)
 			if err != nil {
 				t.Fatalf("RecordList with payload search %q failed: %v", tc.searchTerm, err)
 			}
@@ -826,7 +935,7 @@ func TestRecordQueryPayloadSearch(t *testing.T) {
 			AddPayloadSearch(`"status":"approved"`).
 			AddPayloadSearch(`"status":"draft"`)
 
-		list, err := store.RecordList(query)
+		list, err := store.RecordList(context.Background(), query)
 		if err != nil {
 			t.Fatalf("RecordList with multiple payload search failed: %v", err)
 		}
@@ -861,7 +970,7 @@ func TestRecordQueryPayloadSearch(t *testing.T) {
 			AddPayloadSearch(`"status":"approved"`).
 			AddPayloadSearchNot(`"name":"Tom Brown"`)
 
-		list, err := store.RecordList(query)
+		list, err := store.RecordList(context.Background(), query)
 		if err != nil {
 			t.Fatalf("RecordList with NOT condition failed: %v", err)
 		}
@@ -889,3 +998,179 @@ func TestRecordQueryPayloadSearch(t *testing.T) {
 		}
 	})
 }
+
+// --- Context Tests ---
+
+func TestRecordListWithCancelledContext(t *testing.T) {
+	db := InitDB("test_data_store_record_list_cancelled_context.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_list_cancelled_context",
+		AutomigrateEnabled: true,
+		TimeoutSeconds:     5,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	err = store.RecordCreate(context.Background(), customstore.NewRecord("person"))
+	if err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = store.RecordList(ctx, customstore.RecordQuery())
+	if err == nil {
+		t.Fatalf("Expected error when listing records with a cancelled context, but got nil")
+	}
+
+	// The default timeout must not affect queries that complete in time
+	list, err := store.RecordList(context.Background(), customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("Expected list length 1, but got %d", len(list))
+	}
+}
+
+// sqliteDeadlineRecorder opens SQLite connections recording the deadline of
+// the context of each query. Its type name tells the store it is SQLite
+type sqliteDeadlineRecorder struct {
+	dsn    string
+	driver driver.Driver
+
+	mu        sync.Mutex
+	deadlines []time.Time // the zero time for a query without a deadline
+}
+
+func (r *sqliteDeadlineRecorder) Connect(ctx context.Context) (driver.Conn, error) {
+	return r.Open(r.dsn)
+}
+
+func (r *sqliteDeadlineRecorder) Driver() driver.Driver {
+	return r
+}
+
+func (r *sqliteDeadlineRecorder) Open(name string) (driver.Conn, error) {
+	conn, err := r.driver.Open(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqliteDeadlineConn{Conn: conn, recorder: r}, nil
+}
+
+func (r *sqliteDeadlineRecorder) last() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.deadlines) < 1 {
+		return time.Time{}
+	}
+
+	return r.deadlines[len(r.deadlines)-1]
+}
+
+type sqliteDeadlineConn struct {
+	driver.Conn
+	recorder *sqliteDeadlineRecorder
+}
+
+func (c *sqliteDeadlineConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	deadline, _ := ctx.Deadline()
+
+	c.recorder.mu.Lock()
+	c.recorder.deadlines = append(c.recorder.deadlines, deadline)
+	c.recorder.mu.Unlock()
+
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+func (c *sqliteDeadlineConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func TestRecordListTimeoutSeconds(t *testing.T) {
+	sqlite := InitDB("test_data_store_record_list_timeout.db")
+	defer sqlite.Close()
+
+	recorder := &sqliteDeadlineRecorder{
+		dsn:    "test_data_store_record_list_timeout.db?parseTime=true",
+		driver: sqlite.Driver(),
+	}
+
+	db := sql.OpenDB(recorder)
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_list_timeout",
+		AutomigrateEnabled: true,
+		TimeoutSeconds:     60,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// a context without a deadline gets one from TimeoutSeconds
+	before := time.Now()
+
+	if _, err := store.RecordList(context.Background(), customstore.RecordQuery()); err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+
+	deadline := recorder.last()
+
+	if deadline.IsZero() {
+		t.Fatal("Expected the query to have a deadline from TimeoutSeconds, but it had none")
+	}
+
+	if deadline.Before(before.Add(60*time.Second)) || deadline.After(time.Now().Add(60*time.Second)) {
+		t.Fatalf("Expected the deadline to be 60 seconds after the query, but got %s after", deadline.Sub(before))
+	}
+
+	// the deadline of the caller is kept, shorter or longer
+	for _, timeout := range []time.Duration{10 * time.Second, time.Hour} {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+		if _, err := store.RecordList(ctx, customstore.RecordQuery()); err != nil {
+			cancel()
+			t.Fatalf("RecordList failed: %v", err)
+		}
+
+		expected, _ := ctx.Deadline()
+		cancel()
+
+		if !recorder.last().Equal(expected) {
+			t.Fatalf("Expected the deadline of the caller %s to be kept, but got %s", expected, recorder.last())
+		}
+	}
+
+	// without TimeoutSeconds, no deadline is added
+	store, err = customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_list_timeout",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, err := store.RecordList(context.Background(), customstore.RecordQuery()); err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+
+	if !recorder.last().IsZero() {
+		t.Fatalf("Expected no deadline without TimeoutSeconds, but got %s", recorder.last())
+	}
+}
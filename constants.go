@@ -1,10 +1,43 @@
 package customstore
 
 const COLUMN_CREATED_AT = "created_at"
+const COLUMN_EXPIRES_AT = "expires_at"
 const COLUMN_ID = "id"
 const COLUMN_MEMO = "memo"
 const COLUMN_METAS = "metas"
 const COLUMN_PAYLOAD = "payload"
+const COLUMN_PAYLOAD_VERSION = "payload_version"
 const COLUMN_RECORD_TYPE = "record_type"
 const COLUMN_SOFT_DELETED_AT = "soft_deleted_at"
 const COLUMN_UPDATED_AT = "updated_at"
+const COLUMN_VERSION = "version"
+
+// Columns of the history table, next to the columns of the record
+const COLUMN_CHANGED_AT = "changed_at"
+const COLUMN_HISTORY_ID = "history_id"
+const COLUMN_OPERATION = "operation"
+const COLUMN_REVISION = "revision"
+
+// Operations recorded in the history table
+const HISTORY_OPERATION_CREATE = "create"
+const HISTORY_OPERATION_DELETE = "delete"
+const HISTORY_OPERATION_SOFT_DELETE = "soft_delete"
+const HISTORY_OPERATION_UPDATE = "update"
+
+// Columns of the change feed and checkpoints tables
+const COLUMN_CHANGED = "changed"
+const COLUMN_CONSUMER = "consumer"
+const COLUMN_RECORD_ID = "record_id"
+const COLUMN_SEQUENCE = "sequence"
+
+// Operations recorded in the change feed
+const CHANGE_OPERATION_CREATE = "create"
+const CHANGE_OPERATION_DELETE = "delete"
+const CHANGE_OPERATION_RESTORE = "restore"
+const CHANGE_OPERATION_SOFT_DELETE = "soft_delete"
+const CHANGE_OPERATION_UPDATE = "update"
+
+// Casts applied to a payload field, when ordering by it
+const CAST_AS_DATE = "date"
+const CAST_AS_NUMERIC = "numeric"
+const CAST_AS_TEXT = "text"
@@ -0,0 +1,7 @@
+package customstore
+
+import "errors"
+
+// ErrVersionConflict is returned by RecordUpdate, when versioning is enabled
+// and the record was modified by someone else since it was loaded
+var ErrVersionConflict = errors.New("record version conflict")
@@ -0,0 +1,284 @@
+package customstore
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/gouniverse/sb"
+)
+
+// jsonPathSegmentRegex restricts the segments of a JSON path to plain key names
+var jsonPathSegmentRegex = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+// fieldExpression is an expression (column or extracted JSON value)
+// that conditions and orderings can be applied to
+type fieldExpression interface {
+	exp.Expression
+	exp.Comparable
+	exp.Inable
+	exp.Likeable
+	exp.Isable
+	exp.Orderable
+}
+
+// jsonFieldCondition is a condition on a value inside a JSON column,
+// i.e. the payload or the metas
+type jsonFieldCondition struct {
+	// column is the JSON column, i.e. COLUMN_PAYLOAD or COLUMN_METAS
+	column string
+
+	// path is the dot separated path to the value, i.e. address.city
+	path string
+
+	// operator is one of the supported operators, i.e. =, IN, IS NULL
+	operator string
+
+	// value is the value to compare with, a slice for IN and NOT IN
+	value any
+}
+
+func (c jsonFieldCondition) validate() error {
+	if c.column == COLUMN_METAS {
+		// metas are flat, the path is a single key
+		if !jsonPathSegmentRegex.MatchString(c.path) {
+			return errors.New("meta key " + strconv.Quote(c.path) + " is not valid")
+		}
+	} else if err := validateJSONPath(c.path); err != nil {
+		return err
+	}
+
+	return validateOperator(c.operator, c.value)
+}
+
+func (c jsonFieldCondition) toExpression(driver string) (exp.Expression, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	var field fieldExpression = jsonFieldExtract(driver, c.column, c.path)
+	value := c.value
+
+	if isDriverPostgres(driver) || isDriverMysql(driver) || c.column == COLUMN_METAS {
+		// the extracted value is text, booleans are compared by their JSON text
+		value = boolsToStrings(value)
+	}
+
+	if isDriverPostgres(driver) && isNumericValue(value) {
+		field = goqu.L("(?)::numeric", field)
+	} else if c.column == COLUMN_METAS && isNumericValue(value) {
+		// meta values are always strings, so are cast to compare as numbers
+		field = castJSONField(driver, jsonFieldExtract(driver, c.column, c.path), CAST_AS_NUMERIC)
+	}
+
+	return applyOperator(field, c.operator, value)
+}
+
+// validateJSONPath checks the path is a dot separated list of plain key names
+func validateJSONPath(path string) error {
+	if strings.TrimSpace(path) == "" {
+		return errors.New("json path is required")
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		if !jsonPathSegmentRegex.MatchString(segment) {
+			return errors.New("json path " + strconv.Quote(path) + " contains an invalid segment " + strconv.Quote(segment))
+		}
+	}
+
+	return nil
+}
+
+// validateOperator checks the operator is supported, and the value suits it
+func validateOperator(operator string, value any) error {
+	switch strings.ToUpper(strings.TrimSpace(operator)) {
+	case "=", "!=", "<>", "<", "<=", ">", ">=", "LIKE", "NOT LIKE":
+		return nil
+	case "IN", "NOT IN":
+		if !isSliceValue(value) {
+			return errors.New("operator " + operator + " requires a slice value")
+		}
+		return nil
+	case "IS NULL", "IS NOT NULL":
+		return nil
+	}
+
+	return errors.New("operator " + strconv.Quote(operator) + " is not supported")
+}
+
+// applyOperator applies the operator to the field, returning the condition
+func applyOperator(field fieldExpression, operator string, value any) (exp.Expression, error) {
+	if err := validateOperator(operator, value); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(operator)) {
+	case "=":
+		return field.Eq(value), nil
+	case "!=", "<>":
+		return field.Neq(value), nil
+	case "<":
+		return field.Lt(value), nil
+	case "<=":
+		return field.Lte(value), nil
+	case ">":
+		return field.Gt(value), nil
+	case ">=":
+		return field.Gte(value), nil
+	case "LIKE":
+		return field.Like(value), nil
+	case "NOT LIKE":
+		return field.NotLike(value), nil
+	case "IN":
+		return field.In(value), nil
+	case "NOT IN":
+		return field.NotIn(value), nil
+	case "IS NULL":
+		return field.IsNull(), nil
+	default: // IS NOT NULL
+		return field.IsNotNull(), nil
+	}
+}
+
+// jsonFieldExtract returns the dialect specific expression, which extracts
+// the value at the dot separated path from the JSON column as text
+// (or as the native JSON type on SQLite). An empty column, i.e. the payload
+// of a new record, is not valid JSON, so it is read as NULL
+func jsonFieldExtract(driver string, column string, path string) exp.LiteralExpression {
+	segments := strings.Split(path, ".")
+	source := goqu.L("NULLIF(?, '')", goqu.I(column))
+
+	if isDriverPostgres(driver) {
+		if len(segments) == 1 {
+			return goqu.L("(?::jsonb ->> ?)", source, segments[0])
+		}
+
+		return goqu.L("(?::jsonb #>> ?::text[])", source, "{"+strings.Join(segments, ",")+"}")
+	}
+
+	jsonPath := `$."` + strings.Join(segments, `"."`) + `"`
+
+	if isDriverMysql(driver) {
+		// JSON_UNQUOTE turns a JSON null into the string 'null', so it is
+		// mapped to NULL, as on the other databases
+		return goqu.L("(CASE WHEN JSON_TYPE(JSON_EXTRACT(?, ?)) = 'NULL' THEN NULL ELSE JSON_UNQUOTE(JSON_EXTRACT(?, ?)) END)",
+			source, jsonPath, source, jsonPath)
+	}
+
+	return goqu.L("json_extract(?, ?)", source, jsonPath)
+}
+
+// castJSONField casts the extracted JSON value, so it sorts as a number,
+// a date or text, rather than by the database's own comparison rules
+func castJSONField(driver string, field exp.LiteralExpression, castAs string) exp.LiteralExpression {
+	switch castAs {
+	case CAST_AS_NUMERIC:
+		if isDriverPostgres(driver) {
+			return goqu.L("(?)::numeric", field)
+		}
+		if isDriverMysql(driver) {
+			return goqu.L("CAST(? AS DECIMAL(65,10))", field)
+		}
+		return goqu.L("CAST(? AS REAL)", field)
+	case CAST_AS_DATE:
+		if isDriverPostgres(driver) {
+			return goqu.L("(?)::timestamp", field)
+		}
+		if isDriverMysql(driver) {
+			return goqu.L("CAST(? AS DATETIME)", field)
+		}
+		return goqu.L("datetime(?)", field)
+	default:
+		if isDriverPostgres(driver) || isDriverMysql(driver) {
+			return field // already extracted as text
+		}
+		return goqu.L("CAST(? AS TEXT)", field)
+	}
+}
+
+// validateCastAs checks the cast is one of the supported ones
+func validateCastAs(castAs string) error {
+	switch castAs {
+	case CAST_AS_DATE, CAST_AS_NUMERIC, CAST_AS_TEXT:
+		return nil
+	}
+
+	return errors.New("cast " + strconv.Quote(castAs) + " is not supported")
+}
+
+// validateSortDirection checks the direction is either asc or desc
+func validateSortDirection(direction string) error {
+	if strings.EqualFold(direction, sb.ASC) || strings.EqualFold(direction, sb.DESC) {
+		return nil
+	}
+
+	return errors.New("sort direction " + strconv.Quote(direction) + " is not supported")
+}
+
+func isDriverMysql(driver string) bool {
+	return strings.Contains(strings.ToLower(driver), "mysql")
+}
+
+func isDriverPostgres(driver string) bool {
+	driver = strings.ToLower(driver)
+	return strings.Contains(driver, "postgres") || driver == "pgx" || driver == "pq"
+}
+
+func isSliceValue(value any) bool {
+	if value == nil {
+		return false
+	}
+
+	kind := reflect.TypeOf(value).Kind()
+
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// isNumericValue checks if the value (or the first item of a slice) is a number
+func isNumericValue(value any) bool {
+	if value == nil {
+		return false
+	}
+
+	reflected := reflect.ValueOf(value)
+
+	if isSliceValue(value) {
+		if reflected.Len() < 1 {
+			return false
+		}
+		reflected = reflected.Index(0)
+		if reflected.Kind() == reflect.Interface {
+			reflected = reflected.Elem()
+		}
+	}
+
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// boolsToStrings converts boolean values (or slice items) to their JSON text
+func boolsToStrings(value any) any {
+	if b, ok := value.(bool); ok {
+		return strconv.FormatBool(b)
+	}
+
+	if bools, ok := value.([]bool); ok {
+		strs := make([]string, 0, len(bools))
+		for _, b := range bools {
+			strs = append(strs, strconv.FormatBool(b))
+		}
+		return strs
+	}
+
+	return value
+}
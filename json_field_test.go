@@ -0,0 +1,293 @@
+package customstore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordQueryPayloadWhere(t *testing.T) {
+	db := InitDB("test_data_store_record_query_payload_where.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_query_payload_where",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	payloads := []map[string]any{
+		{"name": "Jon", "status": "active", "age": 30, "address": map[string]any{"city": "London"}},
+		{"name": "Jane", "status": "inactive", "age": 25, "address": map[string]any{"city": "Paris"}},
+		{"name": "Tom", "status": "active", "age": 41, "address": map[string]any{"city": "London"}, "nickname": "Tommy"},
+		// status only appears as a substring of another key
+		{"name": "Ann", "previous_status": "active", "age": 19},
+	}
+
+	for _, payload := range payloads {
+		record := customstore.NewRecord("person")
+		if err := record.SetPayloadMap(payload); err != nil {
+			t.Fatalf("SetPayloadMap failed: %v", err)
+		}
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		query         customstore.RecordQueryInterface
+		expectedCount int
+	}{
+		{"Equals", customstore.RecordQuery().AddPayloadWhere("status", "=", "active"), 2},
+		{"NotEquals", customstore.RecordQuery().AddPayloadWhere("status", "!=", "active"), 1},
+		{"Nested", customstore.RecordQuery().AddPayloadWhere("address.city", "=", "London"), 2},
+		{"LessThan", customstore.RecordQuery().AddPayloadWhere("age", "<", 30), 2},
+		{"GreaterThan", customstore.RecordQuery().AddPayloadWhere("age", ">", 30), 1},
+		{"In", customstore.RecordQuery().AddPayloadWhere("name", "IN", []string{"Jon", "Ann"}), 2},
+		{"Like", customstore.RecordQuery().AddPayloadWhere("name", "LIKE", "J%"), 2},
+		{"IsNull", customstore.RecordQuery().AddPayloadWhere("nickname", "IS NULL", nil), 3},
+		{"Combined", customstore.RecordQuery().
+			AddPayloadWhere("status", "=", "active").
+			AddPayloadWhere("age", ">", 35), 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := store.RecordList(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("RecordList failed: %v", err)
+			}
+
+			if len(list) != tc.expectedCount {
+				t.Fatalf("Expected %d records, but got %d", tc.expectedCount, len(list))
+			}
+		})
+	}
+
+	t.Run("InvalidPath", func(t *testing.T) {
+		_, err := store.RecordList(context.Background(), customstore.RecordQuery().
+			AddPayloadWhere("name') OR 1=1 --", "=", "x"))
+
+		if err == nil {
+			t.Fatalf("Expected error for an invalid path, but got nil")
+		}
+	})
+
+	t.Run("InvalidOperator", func(t *testing.T) {
+		_, err := store.RecordList(context.Background(), customstore.RecordQuery().
+			AddPayloadWhere("name", "REGEXP", "x"))
+
+		if err == nil {
+			t.Fatalf("Expected error for an unsupported operator, but got nil")
+		}
+	})
+}
+
+func TestRecordQueryPayloadWhereEmptyPayload(t *testing.T) {
+	db := InitDB("test_data_store_record_query_payload_where_empty.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_query_payload_where_empty",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// a new record has an empty payload, and empty metas when cleared
+	empty := customstore.NewRecord("person")
+	empty.Set(customstore.COLUMN_METAS, "")
+	if err := store.RecordCreate(context.Background(), empty); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	active := customstore.NewRecord("person")
+	active.SetPayload(`{"status":"active"}`)
+	active.SetMeta("status", "active")
+	if err := store.RecordCreate(context.Background(), active); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	queries := map[string]customstore.RecordQueryInterface{
+		"payload": customstore.RecordQuery().AddPayloadWhere("status", "=", "active"),
+		"metas":   customstore.RecordQuery().AddMetaWhere("status", "=", "active"),
+	}
+
+	for name, query := range queries {
+		list, err := store.RecordList(context.Background(), query)
+
+		if err != nil {
+			t.Fatalf("RecordList on the %s failed: %v", name, err)
+		}
+
+		if len(list) != 1 || list[0].ID() != active.ID() {
+			t.Fatalf("Expected only the record with the %s to match, got %d records", name, len(list))
+		}
+	}
+
+	list, err := store.RecordList(context.Background(), customstore.RecordQuery().
+		AddPayloadWhere("status", "IS NULL", nil))
+
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+
+	if len(list) != 1 || list[0].ID() != empty.ID() {
+		t.Fatalf("Expected the record with an empty payload to match IS NULL, got %d records", len(list))
+	}
+}
+
+func TestRecordQueryPayloadWhereDialects(t *testing.T) {
+	testCases := []struct {
+		driver   string
+		expected string
+	}{
+		{"sqlite", `json_extract(NULLIF("payload", ''), ?)`},
+		{"mysql", `JSON_UNQUOTE(JSON_EXTRACT(NULLIF("payload", ''), ?))`},
+		{"postgres", `NULLIF("payload", '')::jsonb #>> ?::text[]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.driver, func(t *testing.T) {
+
+			q, _, err := customstore.RecordQuery().
+				AddPayloadWhere("address.city", "=", "London").
+				ToSelectDataset(tc.driver, "data")
+
+			if err != nil {
+				t.Fatalf("ToSelectDataset failed: %v", err)
+			}
+
+			sqlStr, params, err := q.Prepared(true).ToSQL()
+			if err != nil {
+				t.Fatalf("ToSQL failed: %v", err)
+			}
+
+			if !strings.Contains(sqlStr, tc.expected) {
+				t.Fatalf("Expected SQL to contain %q, but got %q", tc.expected, sqlStr)
+			}
+
+			if len(params) < 2 {
+				t.Fatalf("Expected the path and the value to be bound as parameters, but got %v", params)
+			}
+		})
+	}
+
+	// on MySQL a JSON null is matched as NULL, as on the other databases
+	q, _, err := customstore.RecordQuery().
+		AddPayloadWhere("deleted_by", "IS NULL", nil).
+		ToSelectDataset("mysql", "data")
+
+	if err != nil {
+		t.Fatalf("ToSelectDataset failed: %v", err)
+	}
+
+	sqlStr, _, err := q.Prepared(true).ToSQL()
+	if err != nil {
+		t.Fatalf("ToSQL failed: %v", err)
+	}
+
+	if !strings.Contains(sqlStr, "JSON_TYPE(JSON_EXTRACT(") || !strings.Contains(sqlStr, "= 'NULL' THEN NULL") {
+		t.Fatalf("Expected a JSON null to be matched as NULL, but got %q", sqlStr)
+	}
+}
+
+func TestRecordQueryMetaWhere(t *testing.T) {
+	db := InitDB("test_data_store_record_query_meta_where.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_query_meta_where",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	metas := []map[string]string{
+		{"owner_id": "1", "priority": "5", "archived": "true"},
+		{"owner_id": "2", "priority": "10"},
+		{"owner_id": "1", "priority": "20"},
+		{},
+	}
+
+	for _, recordMetas := range metas {
+		record := customstore.NewRecord("task")
+		if err := record.SetMetas(recordMetas); err != nil {
+			t.Fatalf("SetMetas failed: %v", err)
+		}
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		query         customstore.RecordQueryInterface
+		expectedCount int
+	}{
+		{"Equals", customstore.RecordQuery().AddMetaWhere("owner_id", "=", "1"), 2},
+		{"In", customstore.RecordQuery().AddMetaWhere("owner_id", "IN", []string{"1", "2"}), 3},
+		{"NumericGreaterThan", customstore.RecordQuery().AddMetaWhere("priority", ">", 9), 2},
+		{"Bool", customstore.RecordQuery().AddMetaWhere("archived", "=", true), 1},
+		{"Exists", customstore.RecordQuery().SetMetaExists("owner_id"), 3},
+		{"Combined", customstore.RecordQuery().
+			AddMetaWhere("owner_id", "=", "1").
+			AddMetaWhere("priority", "<", 10), 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := store.RecordList(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("RecordList failed: %v", err)
+			}
+
+			if len(list) != tc.expectedCount {
+				t.Fatalf("Expected %d records, but got %d", tc.expectedCount, len(list))
+			}
+		})
+	}
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		_, err := store.RecordList(context.Background(), customstore.RecordQuery().
+			SetMetaExists("owner.id"))
+
+		if err == nil {
+			t.Fatalf("Expected error for an invalid meta key, but got nil")
+		}
+	})
+
+	for _, driver := range []string{"mysql", "postgres"} {
+		t.Run("Dialect"+driver, func(t *testing.T) {
+			q, _, err := customstore.RecordQuery().
+				AddMetaWhere("priority", ">", 9).
+				ToSelectDataset(driver, "data")
+
+			if err != nil {
+				t.Fatalf("ToSelectDataset failed: %v", err)
+			}
+
+			sqlStr, _, err := q.Prepared(true).ToSQL()
+			if err != nil {
+				t.Fatalf("ToSQL failed: %v", err)
+			}
+
+			if !strings.Contains(sqlStr, `"metas"`) && !strings.Contains(sqlStr, "`metas`") {
+				t.Fatalf("Expected SQL to extract from the metas column, but got %q", sqlStr)
+			}
+		})
+	}
+}
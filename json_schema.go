@@ -0,0 +1,475 @@
+package customstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rules reported by a schema validation, named after the keywords
+// of JSON Schema, apart from SCHEMA_RULE_JSON for an invalid payload
+const SCHEMA_RULE_ADDITIONAL_PROPERTIES = "additionalProperties"
+const SCHEMA_RULE_CONST = "const"
+const SCHEMA_RULE_ENUM = "enum"
+const SCHEMA_RULE_EXCLUSIVE_MAXIMUM = "exclusiveMaximum"
+const SCHEMA_RULE_EXCLUSIVE_MINIMUM = "exclusiveMinimum"
+const SCHEMA_RULE_FALSE = "false"
+const SCHEMA_RULE_JSON = "json"
+const SCHEMA_RULE_MAX_ITEMS = "maxItems"
+const SCHEMA_RULE_MAX_LENGTH = "maxLength"
+const SCHEMA_RULE_MAXIMUM = "maximum"
+const SCHEMA_RULE_MIN_ITEMS = "minItems"
+const SCHEMA_RULE_MIN_LENGTH = "minLength"
+const SCHEMA_RULE_MINIMUM = "minimum"
+const SCHEMA_RULE_PATTERN = "pattern"
+const SCHEMA_RULE_REQUIRED = "required"
+const SCHEMA_RULE_TYPE = "type"
+
+// jsonSchema is a compiled JSON Schema. The keywords supported are type,
+// enum, const, properties, required, additionalProperties, items, minItems,
+// maxItems, minLength, maxLength, pattern, minimum, maximum,
+// exclusiveMinimum and exclusiveMaximum. The annotations, i.e. title and
+// description, are allowed and ignored. Other keywords are rejected, rather
+// than not enforced
+type jsonSchema struct {
+	// never is set by the false schema, which nothing is valid against
+	never bool
+
+	types                []string
+	enum                 []any
+	constValue           any
+	hasConst             bool
+	properties           map[string]*jsonSchema
+	required             []string
+	additionalProperties *jsonSchema
+	items                *jsonSchema
+	minItems             *int
+	maxItems             *int
+	minLength            *int
+	maxLength            *int
+	pattern              *regexp.Regexp
+	minimum              *float64
+	maximum              *float64
+	exclusiveMinimum     *float64
+	exclusiveMaximum     *float64
+}
+
+// jsonSchemaKeywords are the keywords a schema may hold, the supported ones
+// and the annotations, which do not affect the validation
+var jsonSchemaKeywords = []string{
+	// supported
+	"additionalProperties",
+	"const",
+	"enum",
+	"exclusiveMaximum",
+	"exclusiveMinimum",
+	"items",
+	"maxItems",
+	"maxLength",
+	"maximum",
+	"minItems",
+	"minLength",
+	"minimum",
+	"pattern",
+	"properties",
+	"required",
+	"type",
+
+	// annotations
+	"$comment",
+	"$id",
+	"$schema",
+	"default",
+	"deprecated",
+	"description",
+	"examples",
+	"readOnly",
+	"title",
+	"writeOnly",
+}
+
+// compileJSONSchema parses and compiles a JSON Schema document
+func compileJSONSchema(document string) (*jsonSchema, error) {
+	var doc any
+
+	if err := json.Unmarshal([]byte(document), &doc); err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+
+	return compileJSONSchemaValue(doc, "")
+}
+
+// compileJSONSchemaValue compiles the schema at the path of the document
+func compileJSONSchemaValue(doc any, path string) (*jsonSchema, error) {
+	if allowed, isBool := doc.(bool); isBool {
+		return &jsonSchema{never: !allowed}, nil
+	}
+
+	keywords, isObject := doc.(map[string]any)
+
+	if !isObject {
+		return nil, schemaCompileError(path, "", "must be an object or a boolean")
+	}
+
+	unsupported := []string{}
+	for keyword := range keywords {
+		if !slices.Contains(jsonSchemaKeywords, keyword) {
+			unsupported = append(unsupported, keyword)
+		}
+	}
+
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return nil, schemaCompileError(path, unsupported[0], "is not supported")
+	}
+
+	schema := &jsonSchema{}
+
+	if value, exists := keywords["type"]; exists {
+		switch typed := value.(type) {
+		case string:
+			schema.types = []string{typed}
+		case []any:
+			for _, item := range typed {
+				name, isString := item.(string)
+				if !isString {
+					return nil, schemaCompileError(path, "type", "must be a string or an array of strings")
+				}
+				schema.types = append(schema.types, name)
+			}
+		default:
+			return nil, schemaCompileError(path, "type", "must be a string or an array of strings")
+		}
+
+		for _, name := range schema.types {
+			if !slices.Contains([]string{"array", "boolean", "integer", "null", "number", "object", "string"}, name) {
+				return nil, schemaCompileError(path, "type", "unknown type "+name)
+			}
+		}
+	}
+
+	if value, exists := keywords["enum"]; exists {
+		values, isArray := value.([]any)
+		if !isArray {
+			return nil, schemaCompileError(path, "enum", "must be an array")
+		}
+		schema.enum = values
+	}
+
+	if value, exists := keywords["const"]; exists {
+		schema.constValue = value
+		schema.hasConst = true
+	}
+
+	if value, exists := keywords["properties"]; exists {
+		properties, isObject := value.(map[string]any)
+		if !isObject {
+			return nil, schemaCompileError(path, "properties", "must be an object")
+		}
+
+		schema.properties = map[string]*jsonSchema{}
+		for name, property := range properties {
+			compiled, err := compileJSONSchemaValue(property, schemaPathJoin(path, name))
+			if err != nil {
+				return nil, err
+			}
+			schema.properties[name] = compiled
+		}
+	}
+
+	if value, exists := keywords["required"]; exists {
+		names, isArray := value.([]any)
+		if !isArray {
+			return nil, schemaCompileError(path, "required", "must be an array of strings")
+		}
+
+		for _, item := range names {
+			name, isString := item.(string)
+			if !isString {
+				return nil, schemaCompileError(path, "required", "must be an array of strings")
+			}
+			schema.required = append(schema.required, name)
+		}
+	}
+
+	if value, exists := keywords["additionalProperties"]; exists {
+		compiled, err := compileJSONSchemaValue(value, path)
+		if err != nil {
+			return nil, err
+		}
+		schema.additionalProperties = compiled
+	}
+
+	if value, exists := keywords["items"]; exists {
+		compiled, err := compileJSONSchemaValue(value, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		schema.items = compiled
+	}
+
+	var err error
+
+	if schema.minItems, err = schemaInt(keywords, "minItems", path); err != nil {
+		return nil, err
+	}
+
+	if schema.maxItems, err = schemaInt(keywords, "maxItems", path); err != nil {
+		return nil, err
+	}
+
+	if schema.minLength, err = schemaInt(keywords, "minLength", path); err != nil {
+		return nil, err
+	}
+
+	if schema.maxLength, err = schemaInt(keywords, "maxLength", path); err != nil {
+		return nil, err
+	}
+
+	if schema.minimum, err = schemaNumber(keywords, "minimum", path); err != nil {
+		return nil, err
+	}
+
+	if schema.maximum, err = schemaNumber(keywords, "maximum", path); err != nil {
+		return nil, err
+	}
+
+	if schema.exclusiveMinimum, err = schemaNumber(keywords, "exclusiveMinimum", path); err != nil {
+		return nil, err
+	}
+
+	if schema.exclusiveMaximum, err = schemaNumber(keywords, "exclusiveMaximum", path); err != nil {
+		return nil, err
+	}
+
+	if value, exists := keywords["pattern"]; exists {
+		expression, isString := value.(string)
+		if !isString {
+			return nil, schemaCompileError(path, "pattern", "must be a string")
+		}
+
+		schema.pattern, err = regexp.Compile(expression)
+		if err != nil {
+			return nil, schemaCompileError(path, "pattern", err.Error())
+		}
+	}
+
+	return schema, nil
+}
+
+// validate validates the value at the path against the schema,
+// adding the issues found to the validation error
+func (schema *jsonSchema) validate(value any, path string, result *ValidationError) {
+	if schema.never {
+		result.add(path, SCHEMA_RULE_FALSE, "no value is allowed")
+		return
+	}
+
+	if len(schema.types) > 0 && !slices.ContainsFunc(schema.types, func(name string) bool { return isJSONType(value, name) }) {
+		result.add(path, SCHEMA_RULE_TYPE, "must be of type "+strings.Join(schema.types, " or "))
+		return // the other keywords would only repeat the mismatch
+	}
+
+	if len(schema.enum) > 0 && !slices.ContainsFunc(schema.enum, func(allowed any) bool { return reflect.DeepEqual(allowed, value) }) {
+		result.add(path, SCHEMA_RULE_ENUM, "must be one of the allowed values")
+	}
+
+	if schema.hasConst && !reflect.DeepEqual(schema.constValue, value) {
+		result.add(path, SCHEMA_RULE_CONST, "must be equal to the constant value")
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		schema.validateObject(typed, path, result)
+	case []any:
+		schema.validateArray(typed, path, result)
+	case string:
+		schema.validateString(typed, path, result)
+	case float64:
+		schema.validateNumber(typed, path, result)
+	}
+}
+
+func (schema *jsonSchema) validateObject(object map[string]any, path string, result *ValidationError) {
+	for _, name := range schema.required {
+		if _, exists := object[name]; !exists {
+			result.add(schemaPathJoin(path, name), SCHEMA_RULE_REQUIRED, "is required")
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names) // the issues are reported in a stable order
+
+	for _, name := range names {
+		if property, exists := schema.properties[name]; exists {
+			property.validate(object[name], schemaPathJoin(path, name), result)
+			continue
+		}
+
+		if schema.additionalProperties == nil {
+			continue
+		}
+
+		if schema.additionalProperties.never {
+			result.add(schemaPathJoin(path, name), SCHEMA_RULE_ADDITIONAL_PROPERTIES, "is not allowed")
+			continue
+		}
+
+		schema.additionalProperties.validate(object[name], schemaPathJoin(path, name), result)
+	}
+}
+
+func (schema *jsonSchema) validateArray(array []any, path string, result *ValidationError) {
+	if schema.minItems != nil && len(array) < *schema.minItems {
+		result.add(path, SCHEMA_RULE_MIN_ITEMS, "must have at least "+strconv.Itoa(*schema.minItems)+" items")
+	}
+
+	if schema.maxItems != nil && len(array) > *schema.maxItems {
+		result.add(path, SCHEMA_RULE_MAX_ITEMS, "must have at most "+strconv.Itoa(*schema.maxItems)+" items")
+	}
+
+	if schema.items == nil {
+		return
+	}
+
+	for index, item := range array {
+		schema.items.validate(item, path+"["+strconv.Itoa(index)+"]", result)
+	}
+}
+
+func (schema *jsonSchema) validateString(value string, path string, result *ValidationError) {
+	length := utf8.RuneCountInString(value)
+
+	if schema.minLength != nil && length < *schema.minLength {
+		result.add(path, SCHEMA_RULE_MIN_LENGTH, "must be at least "+strconv.Itoa(*schema.minLength)+" characters long")
+	}
+
+	if schema.maxLength != nil && length > *schema.maxLength {
+		result.add(path, SCHEMA_RULE_MAX_LENGTH, "must be at most "+strconv.Itoa(*schema.maxLength)+" characters long")
+	}
+
+	if schema.pattern != nil && !schema.pattern.MatchString(value) {
+		result.add(path, SCHEMA_RULE_PATTERN, "must match the pattern "+schema.pattern.String())
+	}
+}
+
+func (schema *jsonSchema) validateNumber(value float64, path string, result *ValidationError) {
+	if schema.minimum != nil && value < *schema.minimum {
+		result.add(path, SCHEMA_RULE_MINIMUM, "must be greater than or equal to "+formatSchemaNumber(*schema.minimum))
+	}
+
+	if schema.maximum != nil && value > *schema.maximum {
+		result.add(path, SCHEMA_RULE_MAXIMUM, "must be less than or equal to "+formatSchemaNumber(*schema.maximum))
+	}
+
+	if schema.exclusiveMinimum != nil && value <= *schema.exclusiveMinimum {
+		result.add(path, SCHEMA_RULE_EXCLUSIVE_MINIMUM, "must be greater than "+formatSchemaNumber(*schema.exclusiveMinimum))
+	}
+
+	if schema.exclusiveMaximum != nil && value >= *schema.exclusiveMaximum {
+		result.add(path, SCHEMA_RULE_EXCLUSIVE_MAXIMUM, "must be less than "+formatSchemaNumber(*schema.exclusiveMaximum))
+	}
+}
+
+// isJSONType returns true if the decoded JSON value is of the JSON Schema type
+func isJSONType(value any, name string) bool {
+	switch name {
+	case "array":
+		_, is := value.([]any)
+		return is
+	case "boolean":
+		_, is := value.(bool)
+		return is
+	case "integer":
+		number, is := value.(float64)
+		return is && number == math.Trunc(number)
+	case "null":
+		return value == nil
+	case "number":
+		_, is := value.(float64)
+		return is
+	case "object":
+		_, is := value.(map[string]any)
+		return is
+	case "string":
+		_, is := value.(string)
+		return is
+	}
+
+	return false
+}
+
+// schemaInt returns the non negative integer keyword, if set
+func schemaInt(keywords map[string]any, keyword string, path string) (*int, error) {
+	value, exists := keywords[keyword]
+
+	if !exists {
+		return nil, nil
+	}
+
+	number, isNumber := value.(float64)
+
+	if !isNumber || number < 0 || number != math.Trunc(number) {
+		return nil, schemaCompileError(path, keyword, "must be a non negative integer")
+	}
+
+	integer := int(number)
+
+	return &integer, nil
+}
+
+// schemaNumber returns the number keyword, if set
+func schemaNumber(keywords map[string]any, keyword string, path string) (*float64, error) {
+	value, exists := keywords[keyword]
+
+	if !exists {
+		return nil, nil
+	}
+
+	number, isNumber := value.(float64)
+
+	if !isNumber {
+		return nil, schemaCompileError(path, keyword, "must be a number")
+	}
+
+	return &number, nil
+}
+
+func schemaCompileError(path string, keyword string, message string) error {
+	location := "schema"
+
+	if path != "" {
+		location += " at " + path
+	}
+
+	if keyword != "" {
+		location += ": " + keyword
+	}
+
+	return errors.New(location + " " + message)
+}
+
+// schemaPathJoin returns the path of the property of the object at the path,
+// in the dot notation used by the payload filters
+func schemaPathJoin(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func formatSchemaNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
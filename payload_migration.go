@@ -0,0 +1,130 @@
+package customstore
+
+import (
+	"errors"
+	"maps"
+	"strconv"
+	"sync"
+)
+
+// PayloadMigrationFunc migrates a payload from one version to the next
+type PayloadMigrationFunc func(payload map[string]any) (map[string]any, error)
+
+// payloadMigration is a migration, with the version it migrates to
+type payloadMigration struct {
+	to int
+	fn PayloadMigrationFunc
+}
+
+// payloadMigrations are the registered migrations, by record type and
+// by the version they migrate from
+var payloadMigrations = struct {
+	mu     sync.RWMutex
+	byType map[string]map[int]payloadMigration
+}{
+	byType: map[string]map[int]payloadMigration{},
+}
+
+// RegisterPayloadMigration registers the migration of the payloads of the
+// record type from a version to a later one. Versions start at 1.
+//
+// When payload versioning is enabled, the records read are migrated to the
+// latest version, and the migrated payload is stored on their next update.
+// UpgradeAll stores the migrated payloads of all the records at once.
+func RegisterPayloadMigration(recordType string, from int, to int, fn PayloadMigrationFunc) error {
+	if recordType == "" {
+		return errors.New("record type is empty")
+	}
+
+	if from < 1 {
+		return errors.New("payload version must be greater than zero")
+	}
+
+	if to <= from {
+		return errors.New("payload migration must be to a later version")
+	}
+
+	if fn == nil {
+		return errors.New("payload migration is nil")
+	}
+
+	payloadMigrations.mu.Lock()
+	defer payloadMigrations.mu.Unlock()
+
+	migrations, exists := payloadMigrations.byType[recordType]
+	if !exists {
+		migrations = map[int]payloadMigration{}
+		payloadMigrations.byType[recordType] = migrations
+	}
+
+	if _, exists := migrations[from]; exists {
+		return errors.New("payload migration of " + recordType + " from version " + strconv.Itoa(from) + " already registered")
+	}
+
+	migrations[from] = payloadMigration{to: to, fn: fn}
+
+	return nil
+}
+
+// LatestPayloadVersion returns the latest payload version of the record
+// type, the highest version migrated to, or 1 without migrations
+func LatestPayloadVersion(recordType string) int {
+	payloadMigrations.mu.RLock()
+	defer payloadMigrations.mu.RUnlock()
+
+	latest := 1
+	for _, migration := range payloadMigrations.byType[recordType] {
+		latest = max(latest, migration.to)
+	}
+
+	return latest
+}
+
+// migratePayload migrates the payload of the record to the latest version
+// of its type, and returns true if it was migrated. The record is left
+// unchanged on error
+func migratePayload(record RecordInterface) (bool, error) {
+	payloadMigrations.mu.RLock()
+	migrations := maps.Clone(payloadMigrations.byType[record.Type()])
+	payloadMigrations.mu.RUnlock()
+
+	version := max(record.PayloadVersion(), 1)
+	latest := 1
+	for _, migration := range migrations {
+		latest = max(latest, migration.to)
+	}
+
+	if version >= latest {
+		return false, nil
+	}
+
+	payload, err := record.PayloadMap()
+
+	if err != nil {
+		return false, err
+	}
+
+	for version < latest {
+		migration, exists := migrations[version]
+
+		if !exists {
+			return false, errors.New("no payload migration of " + record.Type() + " from version " + strconv.Itoa(version))
+		}
+
+		payload, err = migration.fn(payload)
+
+		if err != nil {
+			return false, err
+		}
+
+		version = migration.to
+	}
+
+	if err := record.SetPayloadMap(payload); err != nil {
+		return false, err
+	}
+
+	record.SetPayloadVersion(version)
+
+	return true, nil
+}
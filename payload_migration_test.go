@@ -0,0 +1,268 @@
+package customstore_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestPayloadMigration(t *testing.T) {
+	db := InitDB("test_data_payload_migration.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                       db,
+		TableName:                "data_payload_migration",
+		AutomigrateEnabled:       true,
+		PayloadVersioningEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// records stored before the migrations were written
+	legacy := customstore.NewRecord("migrated_person")
+	legacy.SetPayload(`{"fullname":"Ann"}`)
+	if err := store.RecordCreate(ctx, legacy); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	deleted := customstore.NewRecord("migrated_person")
+	deleted.SetPayload(`{"fullname":"Bob"}`)
+	if err := store.RecordCreate(ctx, deleted); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if err := store.RecordSoftDelete(ctx, deleted); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+
+	if legacy.PayloadVersion() != 1 {
+		t.Fatalf("Expected the record to be created at version 1, got %d", legacy.PayloadVersion())
+	}
+
+	err = customstore.RegisterPayloadMigration("migrated_person", 1, 2, func(payload map[string]any) (map[string]any, error) {
+		payload["name"] = payload["fullname"]
+		delete(payload, "fullname")
+		return payload, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterPayloadMigration failed: %v", err)
+	}
+
+	err = customstore.RegisterPayloadMigration("migrated_person", 2, 3, func(payload map[string]any) (map[string]any, error) {
+		payload["country"] = "UK"
+		return payload, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterPayloadMigration failed: %v", err)
+	}
+
+	if err := customstore.RegisterPayloadMigration("migrated_person", 2, 4, nil); err == nil {
+		t.Fatal("Expected RegisterPayloadMigration to reject a nil migration")
+	}
+
+	if err := customstore.RegisterPayloadMigration("migrated_person", 1, 2, func(payload map[string]any) (map[string]any, error) { return payload, nil }); err == nil {
+		t.Fatal("Expected RegisterPayloadMigration to reject a second migration from the same version")
+	}
+
+	if latest := customstore.LatestPayloadVersion("migrated_person"); latest != 3 {
+		t.Fatalf("Expected the latest version to be 3, got %d", latest)
+	}
+
+	// migrated when read
+	found, err := store.RecordFindByID(ctx, legacy.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	if found.Payload() != `{"country":"UK","name":"Ann"}` || found.PayloadVersion() != 3 {
+		t.Fatalf("Expected the payload to be migrated to version 3, got %s at version %d", found.Payload(), found.PayloadVersion())
+	}
+
+	outdated := customstore.RecordQuery().
+		SetType("migrated_person").
+		SetSoftDeletedIncluded(true).
+		Where(customstore.ColumnFilter(customstore.COLUMN_PAYLOAD_VERSION, "<", 3))
+
+	if count, _ := store.RecordCount(ctx, outdated); count != 2 {
+		t.Fatalf("Expected the stored payloads to be left as they are, got %d outdated", count)
+	}
+
+	// the records created now are at the latest version
+	current := customstore.NewRecord("migrated_person")
+	current.SetPayload(`{"name":"Cid","country":"FR"}`)
+	if err := store.RecordCreate(ctx, current); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if current.PayloadVersion() != 3 {
+		t.Fatalf("Expected the record to be created at version 3, got %d", current.PayloadVersion())
+	}
+
+	upgraded, err := store.UpgradeAll(ctx, "migrated_person")
+	if err != nil {
+		t.Fatalf("UpgradeAll failed: %v", err)
+	}
+
+	if upgraded != 2 {
+		t.Fatalf("Expected 2 records to be upgraded, got %d", upgraded)
+	}
+
+	if count, _ := store.RecordCount(ctx, outdated); count != 0 {
+		t.Fatalf("Expected no outdated payload after UpgradeAll, got %d", count)
+	}
+
+	restored, err := store.RecordFindByIDWithDeleted(ctx, deleted.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByIDWithDeleted failed: %v", err)
+	}
+
+	if restored.Payload() != `{"country":"UK","name":"Bob"}` {
+		t.Fatalf("Expected the soft deleted record to be upgraded too, got %s", restored.Payload())
+	}
+}
+
+func TestPayloadMigrationFailure(t *testing.T) {
+	db := InitDB("test_data_payload_migration_failure.db")
+	defer db.Close()
+
+	logs := &bytes.Buffer{}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                       db,
+		TableName:                "data_payload_migration_failure",
+		AutomigrateEnabled:       true,
+		PayloadVersioningEnabled: true,
+		Logger:                   slog.New(slog.NewTextHandler(logs, nil)),
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	record := customstore.NewRecord("failing_person")
+	record.SetPayload(`{"name":"Ann"}`)
+	if err := store.RecordCreate(ctx, record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	errMigration := errors.New("migration failed")
+	err = customstore.RegisterPayloadMigration("failing_person", 1, 2, func(payload map[string]any) (map[string]any, error) {
+		return nil, errMigration
+	})
+	if err != nil {
+		t.Fatalf("RegisterPayloadMigration failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(ctx, record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	if found.Payload() != `{"name":"Ann"}` || found.PayloadVersion() != 1 {
+		t.Fatalf("Expected the record to be left as stored, got %s at version %d", found.Payload(), found.PayloadVersion())
+	}
+
+	if !strings.Contains(logs.String(), "Payload migration failed") || !strings.Contains(logs.String(), record.ID()) {
+		t.Fatalf("Expected the migration error to be logged, got %q", logs.String())
+	}
+
+	if _, err := store.UpgradeAll(ctx, "failing_person"); !errors.Is(err, errMigration) {
+		t.Fatalf("Expected UpgradeAll to fail with the migration error, got %v", err)
+	}
+}
+
+func TestPayloadVersioningEnabledOnExistingTable(t *testing.T) {
+	db := InitDB("test_data_payload_versioning_existing_table.db")
+	defer db.Close()
+
+	options := customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_versioning_existing_table",
+		AutomigrateEnabled: true,
+		HistoryEnabled:     true,
+	}
+
+	store, err := customstore.NewStore(options)
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	legacy := customstore.NewRecord("legacy_person")
+	legacy.SetPayload(`{"fullname":"Ann"}`)
+	if err := store.RecordCreate(ctx, legacy); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	// the payload_version column is added to the existing tables
+	options.PayloadVersioningEnabled = true
+
+	store, err = customstore.NewStore(options)
+
+	if err != nil {
+		t.Fatalf("Store could not be created with payload versioning: %v", err)
+	}
+
+	current := customstore.NewRecord("legacy_person")
+	current.SetPayload(`{"name":"Bob"}`)
+	if err := store.RecordCreate(ctx, current); err != nil {
+		t.Fatalf("RecordCreate failed after enabling payload versioning: %v", err)
+	}
+
+	err = customstore.RegisterPayloadMigration("legacy_person", 1, 2, func(payload map[string]any) (map[string]any, error) {
+		if fullname, exists := payload["fullname"]; exists {
+			payload["name"] = fullname
+			delete(payload, "fullname")
+		}
+		return payload, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterPayloadMigration failed: %v", err)
+	}
+
+	// the rows stored before hold no payload version, and are upgraded too
+	upgraded, err := store.UpgradeAll(ctx, "legacy_person")
+	if err != nil {
+		t.Fatalf("UpgradeAll failed: %v", err)
+	}
+
+	if upgraded != 2 {
+		t.Fatalf("Expected 2 records to be upgraded, got %d", upgraded)
+	}
+
+	outdated := customstore.RecordQuery().
+		SetType("legacy_person").
+		Where(customstore.Or(
+			customstore.ColumnFilter(customstore.COLUMN_PAYLOAD_VERSION, "IS NULL", nil),
+			customstore.ColumnFilter(customstore.COLUMN_PAYLOAD_VERSION, "<", 2),
+		))
+
+	if count, _ := store.RecordCount(ctx, outdated); count != 0 {
+		t.Fatalf("Expected no outdated payload after UpgradeAll, got %d", count)
+	}
+
+	if upgraded, err := store.UpgradeAll(ctx, "legacy_person"); err != nil || upgraded != 0 {
+		t.Fatalf("Expected nothing left to upgrade, got %d (%v)", upgraded, err)
+	}
+
+	// the version column cannot be added to the rows stored before
+	options.VersioningEnabled = true
+
+	if _, err := customstore.NewStore(options); err == nil || !strings.Contains(err.Error(), "column version is missing") {
+		t.Fatalf("Expected enabling versioning on the existing table to fail, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package customstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/dromara/carbon/v2"
+	"github.com/spf13/cast"
+)
+
+// RecordPage is a page of records, returned by RecordListPage
+type RecordPage struct {
+	// Records are the records on the page
+	Records []RecordInterface
+
+	// NextCursor is the cursor for the next page, empty on the last page
+	NextCursor string
+}
+
+// queryCursor is the decoded content of an opaque cursor token, holding the
+// sort values of the last record on the previous page (the id included)
+type queryCursor struct {
+	Values []any `json:"v"`
+}
+
+func encodeCursor(cursor queryCursor) (string, error) {
+	jsonBytes, err := json.Marshal(cursor)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(jsonBytes), nil
+}
+
+func decodeCursor(token string) (queryCursor, error) {
+	cursor := queryCursor{}
+
+	jsonBytes, err := base64.RawURLEncoding.DecodeString(token)
+
+	if err != nil {
+		return cursor, errors.New("cursor is invalid")
+	}
+
+	if err := json.Unmarshal(jsonBytes, &cursor); err != nil {
+		return cursor, errors.New("cursor is invalid")
+	}
+
+	return cursor, nil
+}
+
+// cursorCondition returns the keyset condition selecting the records after
+// the cursor, i.e. for sort keys (a ASC, b DESC) and cursor values (x, y):
+// (a > x) OR (a = x AND b < y)
+//
+// A NULL sort value (i.e. a missing payload field) is compared according to
+// where the database sorts the NULLs, as comparing with NULL is never true
+func cursorCondition(driver string, keys []queryOrder, cursor queryCursor) (exp.Expression, error) {
+	if len(cursor.Values) != len(keys) {
+		return nil, errors.New("cursor does not match the ordering of the query")
+	}
+
+	orConditions := []exp.Expression{}
+
+	for i, key := range keys {
+		after := cursorAfter(driver, key, cursor.Values[i])
+
+		if after == nil {
+			continue // nothing sorts after the value on this key
+		}
+
+		andConditions := []exp.Expression{}
+
+		for j := 0; j < i; j++ {
+			// Eq renders IS NULL for a NULL value
+			andConditions = append(andConditions, keys[j].field(driver).Eq(cursor.Values[j]))
+		}
+
+		andConditions = append(andConditions, after)
+
+		orConditions = append(orConditions, goqu.And(andConditions...))
+	}
+
+	return goqu.Or(orConditions...), nil
+}
+
+// cursorAfter returns the condition selecting the values sorting after the
+// value on the key, or nil if none does
+func cursorAfter(driver string, key queryOrder, value any) exp.Expression {
+	field := key.field(driver)
+
+	if value == nil {
+		if key.nullsFirst(driver) {
+			return field.IsNotNull()
+		}
+
+		return nil
+	}
+
+	var after exp.Expression = field.Lt(value)
+	if key.isAsc() {
+		after = field.Gt(value)
+	}
+
+	if key.nullsFirst(driver) {
+		return after
+	}
+
+	return goqu.Or(after, field.IsNull())
+}
+
+// cursorValue returns the value of the sort key for the record,
+// in the form the database compares it with
+func cursorValue(key queryOrder, record RecordInterface) (any, error) {
+	if !key.isPayloadField() {
+		value := record.Get(key.column)
+
+		if isDateTimeColumn(key.column) {
+			return carbon.Parse(value, carbon.UTC).ToDateTimeString(carbon.UTC), nil
+		}
+
+		return value, nil
+	}
+
+	payload, err := record.PayloadMap()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var value any = payload
+	for _, segment := range strings.Split(key.path, ".") {
+		valueMap, isMap := value.(map[string]any)
+		if !isMap {
+			return nil, nil
+		}
+		value = valueMap[segment]
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	switch key.castAs {
+	case CAST_AS_NUMERIC:
+		return cast.ToFloat64E(value)
+	case CAST_AS_DATE:
+		return carbon.Parse(cast.ToString(value), carbon.UTC).ToDateTimeString(carbon.UTC), nil
+	default:
+		return cast.ToString(value), nil
+	}
+}
+
+// isDateTimeColumn returns true for the columns holding datetime values
+func isDateTimeColumn(column string) bool {
+	switch column {
+	case COLUMN_CREATED_AT, COLUMN_UPDATED_AT, COLUMN_SOFT_DELETED_AT, COLUMN_EXPIRES_AT:
+		return true
+	}
+
+	return false
+}
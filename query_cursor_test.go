@@ -0,0 +1,228 @@
+package customstore_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+	"github.com/gouniverse/sb"
+)
+
+func TestRecordListPage(t *testing.T) {
+	db := InitDB("test_data_store_record_list_page.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_list_page",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{}
+	for i := 0; i < 25; i++ {
+		records = append(records, customstore.NewRecord("person"))
+	}
+	records = append(records, customstore.NewRecord("company"))
+
+	// a single batch, so all the created_at timestamps collide
+	if err := store.RecordCreateMany(context.Background(), records); err != nil {
+		t.Fatalf("RecordCreateMany failed: %v", err)
+	}
+
+	seen := map[string]bool{}
+	cursor := ""
+	pages := 0
+
+	for {
+		query := customstore.RecordQuery().
+			SetType("person").
+			SetOrderBy(customstore.COLUMN_CREATED_AT).
+			SetSortOrder(sb.ASC).
+			SetLimit(10).
+			SetCursor(cursor)
+
+		page, err := store.RecordListPage(context.Background(), query)
+		if err != nil {
+			t.Fatalf("RecordListPage failed: %v", err)
+		}
+
+		pages++
+
+		for _, record := range page.Records {
+			if seen[record.ID()] {
+				t.Fatalf("Record %s returned on more than one page", record.ID())
+			}
+			seen[record.ID()] = true
+		}
+
+		if pages == 1 {
+			// a record inserted while paging must not shift the pages
+			if err := store.RecordCreate(context.Background(), customstore.NewRecord("person")); err != nil {
+				t.Fatalf("RecordCreate failed: %v", err)
+			}
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		cursor = page.NextCursor
+	}
+
+	if pages != 3 {
+		t.Fatalf("Expected 3 pages, but got %d", pages)
+	}
+
+	if len(seen) != 26 {
+		t.Fatalf("Expected 26 distinct person records, but got %d", len(seen))
+	}
+
+	_, err = store.RecordListPage(context.Background(), customstore.RecordQuery().SetCursor("not-a-cursor"))
+	if err == nil {
+		t.Fatalf("Expected error for an invalid cursor, but got nil")
+	}
+}
+
+func TestRecordListPageByPayloadField(t *testing.T) {
+	db := InitDB("test_data_store_record_list_page_payload.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_list_page_payload",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	prices := []float64{5, 1.5, 30, 1.5, 12, 7}
+	for _, price := range prices {
+		record := customstore.NewRecord("product")
+		if err := record.SetPayloadMap(map[string]any{"price": price}); err != nil {
+			t.Fatalf("SetPayloadMap failed: %v", err)
+		}
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	listed := []float64{}
+	cursor := ""
+
+	for {
+		page, err := store.RecordListPage(context.Background(), customstore.RecordQuery().
+			SetOrderByPayloadField("price", sb.DESC, customstore.CAST_AS_NUMERIC).
+			SetLimit(4).
+			SetCursor(cursor))
+
+		if err != nil {
+			t.Fatalf("RecordListPage failed: %v", err)
+		}
+
+		for _, record := range page.Records {
+			price, _ := record.PayloadMapKey("price")
+			listed = append(listed, price.(float64))
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		cursor = page.NextCursor
+	}
+
+	expected := []float64{30, 12, 7, 5, 1.5, 1.5}
+	if len(listed) != len(expected) {
+		t.Fatalf("Expected %v, but got %v", expected, listed)
+	}
+
+	for i := range expected {
+		if listed[i] != expected[i] {
+			t.Fatalf("Expected %v, but got %v", expected, listed)
+		}
+	}
+}
+
+func TestRecordListPageMissingPayloadField(t *testing.T) {
+	db := InitDB("test_data_store_record_list_page_missing.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_list_page_missing",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// the empty payload is the one of a new record
+	payloads := []string{`{"price":10}`, `{}`, `{"price":5}`, `{"price":null}`, ``}
+	for _, payload := range payloads {
+		record := customstore.NewRecord("product")
+		record.SetPayload(payload)
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		direction string
+		expected  []string
+	}{
+		// SQLite sorts the NULLs first
+		{sb.ASC, []string{"", "", "", "5", "10"}},
+		{sb.DESC, []string{"10", "5", "", "", ""}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.direction, func(t *testing.T) {
+			listed := []string{}
+			seen := map[string]bool{}
+			cursor := ""
+
+			for {
+				page, err := store.RecordListPage(context.Background(), customstore.RecordQuery().
+					SetOrderByPayloadField("price", tc.direction, customstore.CAST_AS_NUMERIC).
+					SetLimit(1).
+					SetCursor(cursor))
+
+				if err != nil {
+					t.Fatalf("RecordListPage failed: %v", err)
+				}
+
+				for _, record := range page.Records {
+					if seen[record.ID()] {
+						t.Fatalf("Record %s listed twice", record.ID())
+					}
+					seen[record.ID()] = true
+
+					price, _ := record.PayloadMapKey("price")
+					if price == nil {
+						listed = append(listed, "")
+					} else {
+						listed = append(listed, fmt.Sprint(price))
+					}
+				}
+
+				if page.NextCursor == "" {
+					break
+				}
+
+				cursor = page.NextCursor
+			}
+
+			if fmt.Sprint(listed) != fmt.Sprint(tc.expected) {
+				t.Fatalf("Expected %q, but got %q", tc.expected, listed)
+			}
+		})
+	}
+}
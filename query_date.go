@@ -0,0 +1,38 @@
+package customstore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// toDateTimeString normalises a datetime value (string, time.Time or carbon)
+// to a UTC datetime string, in the format NewRecord writes timestamps in
+func toDateTimeString(value any) (string, error) {
+	var c *carbon.Carbon
+
+	switch v := value.(type) {
+	case string:
+		c = carbon.Parse(v, carbon.UTC)
+	case time.Time:
+		c = carbon.CreateFromStdTime(v)
+	case *time.Time:
+		if v == nil {
+			return "", errors.New("datetime is nil")
+		}
+		c = carbon.CreateFromStdTime(*v)
+	case carbon.Carbon:
+		c = &v
+	case *carbon.Carbon:
+		c = v
+	default:
+		return "", errors.New("datetime must be a string, time.Time or carbon.Carbon")
+	}
+
+	if c == nil || c.HasError() || c.IsInvalid() {
+		return "", errors.New("datetime is invalid")
+	}
+
+	return c.ToDateTimeString(carbon.UTC), nil
+}
@@ -0,0 +1,85 @@
+package customstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordQueryDateRanges(t *testing.T) {
+	db := InitDB("test_data_store_record_query_date_ranges.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_query_date_ranges",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	createdAts := []string{
+		"2024-01-01 10:00:00",
+		"2024-01-05 10:00:00",
+		"2024-01-09 10:00:00",
+	}
+
+	for _, createdAt := range createdAts {
+		record := customstore.NewRecord("order")
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+
+		record.SetCreatedAt(createdAt)
+		if err := store.RecordUpdate(context.Background(), record); err != nil {
+			t.Fatalf("RecordUpdate failed: %v", err)
+		}
+	}
+
+	deleted := customstore.NewRecord("order")
+	if err := store.RecordCreate(context.Background(), deleted); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordSoftDelete(context.Background(), deleted); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+
+	testCases := []struct {
+		name          string
+		query         customstore.RecordQueryInterface
+		expectedCount int
+	}{
+		{"CreatedAtGteString", customstore.RecordQuery().SetCreatedAtGte("2024-01-05 00:00:00"), 2},
+		{"CreatedAtLteTime", customstore.RecordQuery().SetCreatedAtLte(time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC)), 2},
+		{"CreatedAtRangeCarbon", customstore.RecordQuery().
+			SetCreatedAtGte(carbon.Parse("2024-01-02", carbon.UTC)).
+			SetCreatedAtLte(carbon.Parse("2024-01-08", carbon.UTC)), 1},
+		{"CreatedAtTimezone", customstore.RecordQuery().
+			SetCreatedAtGte(time.Date(2024, 1, 9, 11, 0, 0, 0, time.FixedZone("CET", 3600))), 1},
+		{"UpdatedAtGte", customstore.RecordQuery().SetUpdatedAtGte(carbon.Now(carbon.UTC).SubHour()), 3},
+		{"SoftDeletedAtGte", customstore.RecordQuery().SetSoftDeletedAtGte(carbon.Now(carbon.UTC).SubHour()), 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := store.RecordList(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("RecordList failed: %v", err)
+			}
+
+			if len(list) != tc.expectedCount {
+				t.Fatalf("Expected %d records, but got %d", tc.expectedCount, len(list))
+			}
+		})
+	}
+
+	_, err = store.RecordList(context.Background(), customstore.RecordQuery().SetCreatedAtGte(42))
+	if err == nil {
+		t.Fatalf("Expected error for an unsupported datetime value, but got nil")
+	}
+}
@@ -0,0 +1,183 @@
+package customstore
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// FilterInterface is a node of a filter tree, built with And, Or, Not,
+// ColumnFilter, PayloadFilter and MetaFilter, and added to a query with
+// RecordQueryInterface.Where, i.e.
+//
+//	Where(Or(
+//		And(ColumnFilter("record_type", "=", "a"), PayloadFilter("status", "=", "x")),
+//		And(ColumnFilter("record_type", "=", "b"), PayloadFilter("priority", ">", 3)),
+//	))
+type FilterInterface interface {
+	validate() error
+	toExpression(driver string) (exp.Expression, error)
+}
+
+// filterColumns are the columns a ColumnFilter may be applied to
+var filterColumns = []string{
+	COLUMN_ID,
+	COLUMN_RECORD_TYPE,
+	COLUMN_MEMO,
+	COLUMN_CREATED_AT,
+	COLUMN_UPDATED_AT,
+	COLUMN_SOFT_DELETED_AT,
+	COLUMN_VERSION,
+	COLUMN_EXPIRES_AT,
+	COLUMN_PAYLOAD_VERSION,
+}
+
+// And matches when all the filters match, an empty And matches everything
+func And(filters ...FilterInterface) FilterInterface {
+	return filterGroup{and: true, filters: filters}
+}
+
+// Or matches when any of the filters matches, an empty Or matches nothing
+func Or(filters ...FilterInterface) FilterInterface {
+	return filterGroup{and: false, filters: filters}
+}
+
+// Not matches when the filter does not match
+func Not(filter FilterInterface) FilterInterface {
+	return filterNot{filter: filter}
+}
+
+// ColumnFilter is a condition on a column of the table, i.e.
+// ColumnFilter("record_type", "IN", []string{"a", "b"}). The datetime
+// columns accept a string, time.Time or carbon.Carbon value
+func ColumnFilter(column string, operator string, value any) FilterInterface {
+	return columnFilter{column: column, operator: operator, value: value}
+}
+
+// PayloadFilter is a condition on a payload field, as AddPayloadWhere
+func PayloadFilter(path string, operator string, value any) FilterInterface {
+	return jsonFieldCondition{column: COLUMN_PAYLOAD, path: path, operator: operator, value: value}
+}
+
+// MetaFilter is a condition on a meta, as AddMetaWhere
+func MetaFilter(key string, operator string, value any) FilterInterface {
+	return jsonFieldCondition{column: COLUMN_METAS, path: key, operator: operator, value: value}
+}
+
+// filterGroup combines filters with AND or OR
+type filterGroup struct {
+	and     bool
+	filters []FilterInterface
+}
+
+func (f filterGroup) validate() error {
+	for _, filter := range f.filters {
+		if filter == nil {
+			return errors.New("filter is nil")
+		}
+
+		if err := filter.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f filterGroup) toExpression(driver string) (exp.Expression, error) {
+	if len(f.filters) < 1 {
+		if f.and {
+			return goqu.L("1 = 1"), nil
+		}
+		return goqu.L("1 = 0"), nil
+	}
+
+	expressions := make([]exp.Expression, 0, len(f.filters))
+	for _, filter := range f.filters {
+		if filter == nil {
+			return nil, errors.New("filter is nil")
+		}
+
+		expression, err := filter.toExpression(driver)
+		if err != nil {
+			return nil, err
+		}
+
+		expressions = append(expressions, expression)
+	}
+
+	if f.and {
+		return goqu.And(expressions...), nil
+	}
+
+	return goqu.Or(expressions...), nil
+}
+
+// filterNot negates a filter
+type filterNot struct {
+	filter FilterInterface
+}
+
+func (f filterNot) validate() error {
+	if f.filter == nil {
+		return errors.New("filter is nil")
+	}
+
+	return f.filter.validate()
+}
+
+func (f filterNot) toExpression(driver string) (exp.Expression, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	expression, err := f.filter.toExpression(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return goqu.L("NOT (?)", expression), nil
+}
+
+// columnFilter is a condition on a column of the table
+type columnFilter struct {
+	column   string
+	operator string
+	value    any
+}
+
+func (f columnFilter) validate() error {
+	isFilterColumn := false
+	for _, column := range filterColumns {
+		if f.column == column {
+			isFilterColumn = true
+			break
+		}
+	}
+
+	if !isFilterColumn {
+		return errors.New("column " + strconv.Quote(f.column) + " cannot be filtered on")
+	}
+
+	return validateOperator(f.operator, f.value)
+}
+
+func (f columnFilter) toExpression(driver string) (exp.Expression, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	value := f.value
+
+	if isDateTimeColumn(f.column) && value != nil && !isSliceValue(value) {
+		dateTime, err := toDateTimeString(value)
+		if err != nil {
+			return nil, err
+		}
+		value = dateTime
+	}
+
+	return applyOperator(goqu.C(f.column), f.operator, value)
+}
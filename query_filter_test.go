@@ -0,0 +1,134 @@
+package customstore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordQueryWhereFilterTree(t *testing.T) {
+	db := InitDB("test_data_store_record_query_filter_tree.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_query_filter_tree",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []struct {
+		recordType string
+		payload    map[string]any
+		metas      map[string]string
+	}{
+		{"a", map[string]any{"status": "x", "priority": 1}, map[string]string{"owner_id": "1"}},
+		{"a", map[string]any{"status": "y", "priority": 5}, map[string]string{"owner_id": "2"}},
+		{"b", map[string]any{"status": "x", "priority": 2}, map[string]string{"owner_id": "1"}},
+		{"b", map[string]any{"status": "y", "priority": 7}, map[string]string{"owner_id": "2"}},
+	}
+
+	for _, data := range records {
+		record := customstore.NewRecord(data.recordType)
+		if err := record.SetPayloadMap(data.payload); err != nil {
+			t.Fatalf("SetPayloadMap failed: %v", err)
+		}
+		if err := record.SetMetas(data.metas); err != nil {
+			t.Fatalf("SetMetas failed: %v", err)
+		}
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		filter        customstore.FilterInterface
+		expectedCount int
+	}{
+		{"OrOfAnds", customstore.Or(
+			customstore.And(
+				customstore.ColumnFilter(customstore.COLUMN_RECORD_TYPE, "=", "a"),
+				customstore.PayloadFilter("status", "=", "x"),
+			),
+			customstore.And(
+				customstore.ColumnFilter(customstore.COLUMN_RECORD_TYPE, "=", "b"),
+				customstore.PayloadFilter("priority", ">", 3),
+			),
+		), 2},
+		{"Not", customstore.Not(customstore.MetaFilter("owner_id", "=", "1")), 2},
+		{"NestedNot", customstore.And(
+			customstore.ColumnFilter(customstore.COLUMN_RECORD_TYPE, "IN", []string{"a", "b"}),
+			customstore.Not(customstore.Or(
+				customstore.PayloadFilter("status", "=", "x"),
+				customstore.MetaFilter("owner_id", "=", "2"),
+			)),
+		), 0},
+		{"DateColumn", customstore.ColumnFilter(customstore.COLUMN_CREATED_AT, ">", "2000-01-01"), 4},
+		{"EmptyAnd", customstore.And(), 4},
+		{"EmptyOr", customstore.Or(), 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := store.RecordList(context.Background(), customstore.RecordQuery().Where(tc.filter))
+			if err != nil {
+				t.Fatalf("RecordList failed: %v", err)
+			}
+
+			if len(list) != tc.expectedCount {
+				t.Fatalf("Expected %d records, but got %d", tc.expectedCount, len(list))
+			}
+		})
+	}
+
+	t.Run("InvalidColumn", func(t *testing.T) {
+		_, err := store.RecordList(context.Background(), customstore.RecordQuery().
+			Where(customstore.ColumnFilter("payload; DROP TABLE x", "=", "1")))
+
+		if err == nil {
+			t.Fatalf("Expected error for an invalid column, but got nil")
+		}
+	})
+
+	t.Run("NilFilter", func(t *testing.T) {
+		_, err := store.RecordList(context.Background(), customstore.RecordQuery().
+			Where(customstore.And(nil)))
+
+		if err == nil {
+			t.Fatalf("Expected error for a nil filter, but got nil")
+		}
+	})
+}
+
+func TestRecordQueryWhereFilterTreeSQL(t *testing.T) {
+	q, _, err := customstore.RecordQuery().
+		Where(customstore.Or(
+			customstore.And(
+				customstore.ColumnFilter(customstore.COLUMN_RECORD_TYPE, "=", "a"),
+				customstore.PayloadFilter("status", "=", "x"),
+			),
+			customstore.Not(customstore.MetaFilter("owner_id", "=", "1")),
+		)).
+		ToSelectDataset("postgres", "data")
+
+	if err != nil {
+		t.Fatalf("ToSelectDataset failed: %v", err)
+	}
+
+	sqlStr, _, err := q.Prepared(true).ToSQL()
+	if err != nil {
+		t.Fatalf("ToSQL failed: %v", err)
+	}
+
+	for _, expected := range []string{`("record_type" = ?)`, " OR NOT (", `NULLIF("metas", '')::jsonb ->> `} {
+		if !strings.Contains(sqlStr, expected) {
+			t.Fatalf("Expected SQL to contain %q, but got %q", expected, sqlStr)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package customstore
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/gouniverse/sb"
+)
+
+// queryOrder is a single sort key, either a column or a payload field
+type queryOrder struct {
+	// column is the column to order by, empty when ordering by a payload field
+	column string
+
+	// path is the dot separated path to the payload field, i.e. product.price
+	path string
+
+	// castAs is one of CAST_AS_NUMERIC, CAST_AS_TEXT or CAST_AS_DATE,
+	// used only when ordering by a payload field
+	castAs string
+
+	// direction is either sb.ASC or sb.DESC
+	direction string
+}
+
+func (o queryOrder) validate() error {
+	if o.column == "" && o.path == "" {
+		return errors.New("order by column is required")
+	}
+
+	if err := validateSortDirection(o.direction); err != nil {
+		return err
+	}
+
+	if o.column != "" {
+		return nil
+	}
+
+	if err := validateJSONPath(o.path); err != nil {
+		return err
+	}
+
+	return validateCastAs(o.castAs)
+}
+
+// isPayloadField returns true if the sort key is a payload field
+func (o queryOrder) isPayloadField() bool {
+	return o.column == ""
+}
+
+// isAsc returns true if the sort key is in ascending order
+func (o queryOrder) isAsc() bool {
+	return strings.EqualFold(o.direction, sb.ASC)
+}
+
+// field returns the expression being sorted on
+func (o queryOrder) field(driver string) fieldExpression {
+	if o.isPayloadField() {
+		return castJSONField(driver, jsonFieldExtract(driver, COLUMN_PAYLOAD, o.path), o.castAs)
+	}
+
+	return goqu.I(o.column)
+}
+
+// toOrderedExpression returns the dialect specific ORDER BY expression
+func (o queryOrder) toOrderedExpression(driver string) exp.OrderedExpression {
+	if o.isAsc() {
+		return o.field(driver).Asc()
+	}
+
+	return o.field(driver).Desc()
+}
+
+// nullsFirst returns true if the database sorts the NULLs first, in the
+// direction of the key. Postgres sorts them as the largest values, the
+// other databases as the smallest
+func (o queryOrder) nullsFirst(driver string) bool {
+	if isDriverPostgres(driver) {
+		return !o.isAsc()
+	}
+
+	return o.isAsc()
+}
@@ -0,0 +1,195 @@
+package customstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+	"github.com/gouniverse/sb"
+)
+
+func TestRecordQueryOrderByPayloadField(t *testing.T) {
+	db := InitDB("test_data_store_record_query_order_by_payload_field.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_query_order_by_payload_field",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// Prices stored as strings sort differently as text and as numbers
+	payloads := []map[string]any{
+		{"name": "B", "price": "9.50", "released": "2024-03-01"},
+		{"name": "A", "price": "100", "released": "2023-12-31"},
+		{"name": "C", "price": "20", "released": "2024-01-15"},
+	}
+
+	for _, payload := range payloads {
+		record := customstore.NewRecord("product")
+		if err := record.SetPayloadMap(payload); err != nil {
+			t.Fatalf("SetPayloadMap failed: %v", err)
+		}
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		path      string
+		direction string
+		castAs    string
+		expected  []string
+	}{
+		{"NumericAsc", "price", sb.ASC, customstore.CAST_AS_NUMERIC, []string{"B", "C", "A"}},
+		{"NumericDesc", "price", sb.DESC, customstore.CAST_AS_NUMERIC, []string{"A", "C", "B"}},
+		{"TextAsc", "price", sb.ASC, customstore.CAST_AS_TEXT, []string{"A", "C", "B"}},
+		{"DateAsc", "released", sb.ASC, customstore.CAST_AS_DATE, []string{"A", "C", "B"}},
+		{"NameDesc", "name", sb.DESC, "", []string{"C", "B", "A"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := store.RecordList(context.Background(), customstore.RecordQuery().
+				SetOrderByPayloadField(tc.path, tc.direction, tc.castAs))
+
+			if err != nil {
+				t.Fatalf("RecordList failed: %v", err)
+			}
+
+			names := []string{}
+			for _, record := range list {
+				name, err := record.PayloadMapKey("name")
+				if err != nil {
+					t.Fatalf("PayloadMapKey failed: %v", err)
+				}
+				names = append(names, name.(string))
+			}
+
+			if len(names) != len(tc.expected) {
+				t.Fatalf("Expected %v, but got %v", tc.expected, names)
+			}
+
+			for i := range names {
+				if names[i] != tc.expected[i] {
+					t.Fatalf("Expected %v, but got %v", tc.expected, names)
+				}
+			}
+		})
+	}
+
+	// a record without a payload sorts as NULL, first on SQLite
+	empty := customstore.NewRecord("product")
+	if err := store.RecordCreate(context.Background(), empty); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	list, err := store.RecordList(context.Background(), customstore.RecordQuery().
+		SetOrderByPayloadField("price", sb.ASC, customstore.CAST_AS_NUMERIC))
+
+	if err != nil {
+		t.Fatalf("RecordList with an empty payload failed: %v", err)
+	}
+
+	if len(list) != len(payloads)+1 || list[0].ID() != empty.ID() {
+		t.Fatalf("Expected the record without a payload to be listed first, got %d records", len(list))
+	}
+
+	_, err = store.RecordList(context.Background(), customstore.RecordQuery().
+		SetOrderByPayloadField("price", "sideways", customstore.CAST_AS_NUMERIC))
+
+	if err == nil {
+		t.Fatalf("Expected error for an unsupported sort direction, but got nil")
+	}
+}
+
+func TestRecordQuerySortOrderAndMultipleOrderBy(t *testing.T) {
+	db := InitDB("test_data_store_record_query_sort_order.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_query_sort_order",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{}
+	for _, memo := range []string{"b", "a", "b", "a"} {
+		record := customstore.NewRecord("note")
+		record.SetMemo(memo)
+		records = append(records, record)
+	}
+
+	// created in a single batch, so all the timestamps collide
+	if err := store.RecordCreateMany(context.Background(), records); err != nil {
+		t.Fatalf("RecordCreateMany failed: %v", err)
+	}
+
+	listIDs := func(query customstore.RecordQueryInterface) []string {
+		list, err := store.RecordList(context.Background(), query)
+		if err != nil {
+			t.Fatalf("RecordList failed: %v", err)
+		}
+		ids := []string{}
+		for _, record := range list {
+			ids = append(ids, record.Memo()+":"+record.ID())
+		}
+		return ids
+	}
+
+	asc := listIDs(customstore.RecordQuery().SetOrderBy(customstore.COLUMN_MEMO).SetSortOrder(sb.ASC))
+	if asc[0][:1] != "a" || asc[3][:1] != "b" {
+		t.Fatalf("Expected ascending memos, but got %v", asc)
+	}
+
+	desc := listIDs(customstore.RecordQuery().SetOrderBy(customstore.COLUMN_MEMO))
+	if desc[0][:1] != "b" || desc[3][:1] != "a" {
+		t.Fatalf("Expected descending memos by default, but got %v", desc)
+	}
+
+	// created_at descending, memo ascending, then the id tiebreaker
+	// (in the direction of the first sort key) keeps the order stable
+	multi := customstore.RecordQuery().
+		AddOrderBy(customstore.COLUMN_CREATED_AT, sb.DESC).
+		AddOrderBy(customstore.COLUMN_MEMO, sb.ASC)
+
+	first := listIDs(multi)
+	if first[0][:1] != "a" || first[1][:1] != "a" {
+		t.Fatalf("Expected memo to be the second sort key, but got %v", first)
+	}
+	if first[0] < first[1] || first[2] < first[3] {
+		t.Fatalf("Expected records with equal sort values to be ordered by id, but got %v", first)
+	}
+
+	paged := []string{}
+	for offset := 0; offset < 4; offset++ {
+		paged = append(paged, listIDs(customstore.RecordQuery().
+			AddOrderBy(customstore.COLUMN_CREATED_AT, sb.DESC).
+			AddOrderBy(customstore.COLUMN_MEMO, sb.ASC).
+			SetLimit(1).
+			SetOffset(offset))...)
+	}
+
+	for i := range first {
+		if first[i] != paged[i] {
+			t.Fatalf("Expected paginated order %v to match %v", paged, first)
+		}
+	}
+
+	_, err = store.RecordList(context.Background(), customstore.RecordQuery().
+		SetOrderBy(customstore.COLUMN_MEMO).
+		SetSortOrder("random"))
+
+	if err == nil {
+		t.Fatalf("Expected error for an unsupported sort order, but got nil")
+	}
+}
@@ -0,0 +1,176 @@
+package customstore_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordQuerySetFilters(t *testing.T) {
+	db := InitDB("test_data_store_record_query_set_filters.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_query_set_filters",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ids := []string{}
+	for _, recordType := range []string{"order", "order", "invoice", "customer"} {
+		record := customstore.NewRecord(recordType)
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		ids = append(ids, record.ID())
+	}
+
+	testCases := []struct {
+		name          string
+		query         customstore.RecordQueryInterface
+		expectedCount int
+	}{
+		{"IDIn", customstore.RecordQuery().SetIDIn([]string{ids[0], ids[2]}), 2},
+		{"IDInEmpty", customstore.RecordQuery().SetIDIn([]string{}), 0},
+		{"IDNotIn", customstore.RecordQuery().SetIDNotIn([]string{ids[0]}), 3},
+		{"IDNotInEmpty", customstore.RecordQuery().SetIDNotIn([]string{}), 4},
+		{"TypeIn", customstore.RecordQuery().SetTypeIn([]string{"order", "invoice"}), 3},
+		{"TypeNotIn", customstore.RecordQuery().SetTypeNotIn([]string{"order"}), 2},
+		{"TypeInAndIDNotIn", customstore.RecordQuery().
+			SetTypeIn([]string{"order"}).
+			SetIDNotIn([]string{ids[1]}), 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := store.RecordList(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("RecordList failed: %v", err)
+			}
+
+			if len(list) != tc.expectedCount {
+				t.Fatalf("Expected %d records, got %d", tc.expectedCount, len(list))
+			}
+
+			count, err := store.RecordCount(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("RecordCount failed: %v", err)
+			}
+
+			if count != int64(tc.expectedCount) {
+				t.Fatalf("Expected count %d, got %d", tc.expectedCount, count)
+			}
+		})
+	}
+}
+
+func TestRecordDeleteByQuery(t *testing.T) {
+	db := InitDB("test_data_store_record_delete_by_query.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_delete_by_query",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, recordType := range []string{"order", "order", "invoice", "customer"} {
+		if err := store.RecordCreate(context.Background(), customstore.NewRecord(recordType)); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	softDeleted, err := store.RecordSoftDeleteByQuery(context.Background(), customstore.RecordQuery().
+		SetTypeIn([]string{"order"}))
+	if err != nil {
+		t.Fatalf("RecordSoftDeleteByQuery failed: %v", err)
+	}
+
+	if softDeleted != 2 {
+		t.Fatalf("Expected 2 soft deleted records, got %d", softDeleted)
+	}
+
+	deleted, err := store.RecordDeleteByQuery(context.Background(), customstore.RecordQuery().
+		SetTypeNotIn([]string{"customer"}))
+	if err != nil {
+		t.Fatalf("RecordDeleteByQuery failed: %v", err)
+	}
+
+	if deleted != 1 {
+		t.Fatalf("Expected 1 deleted record, got %d", deleted)
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery().
+		SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+
+	if count != 3 {
+		t.Fatalf("Expected 3 records left, got %d", count)
+	}
+}
+
+func TestRecordDeleteByQueryManyIDs(t *testing.T) {
+	db := InitDB("test_data_store_record_delete_by_query_many_ids.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_delete_by_query_many_ids",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{}
+	for i := 0; i < 3; i++ {
+		records = append(records, customstore.NewRecord("order"))
+	}
+
+	if err := store.RecordCreateMany(context.Background(), records); err != nil {
+		t.Fatalf("RecordCreateMany failed: %v", err)
+	}
+
+	// more IDs than SQLite binds in a single statement
+	ids := []string{records[0].ID(), records[1].ID()}
+	for i := 0; i < 40000; i++ {
+		ids = append(ids, "missing-"+strconv.Itoa(i))
+	}
+
+	query := customstore.RecordQuery().SetIDIn(ids)
+
+	deleted, err := store.RecordDeleteByQuery(context.Background(), query)
+	if err != nil {
+		t.Fatalf("RecordDeleteByQuery failed: %v", err)
+	}
+
+	if deleted != 2 {
+		t.Fatalf("Expected 2 deleted records, got %d", deleted)
+	}
+
+	if len(query.GetIDIn()) != len(ids) {
+		t.Fatalf("Expected the IDs of the query to be kept, got %d", len(query.GetIDIn()))
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("Expected 1 record left, got %d", count)
+	}
+}
@@ -0,0 +1,37 @@
+package customstore
+
+import (
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// likeEscapeChar is used to escape the LIKE wildcards in search text. The
+// backslash is avoided, as MySQL treats it as an escape in string literals
+const likeEscapeChar = "!"
+
+var likeEscaper = strings.NewReplacer(
+	likeEscapeChar, likeEscapeChar+likeEscapeChar,
+	"%", likeEscapeChar+"%",
+	"_", likeEscapeChar+"_",
+)
+
+// likeInsensitive returns a case insensitive LIKE condition. Both sides are
+// lowered, as Postgres compares case sensitively, unlike MySQL
+func likeInsensitive(column string, pattern string) exp.Expression {
+	return goqu.L("LOWER(?) LIKE LOWER(?)", goqu.I(column), pattern)
+}
+
+// searchTextCondition matches the text anywhere in the memo, the payload or
+// the metas. The LIKE wildcards in the text are matched literally
+func searchTextCondition(text string) exp.Expression {
+	pattern := "%" + likeEscaper.Replace(text) + "%"
+
+	conditions := []exp.Expression{}
+	for _, column := range []string{COLUMN_MEMO, COLUMN_PAYLOAD, COLUMN_METAS} {
+		conditions = append(conditions, goqu.L("LOWER(?) LIKE LOWER(?) ESCAPE '"+likeEscapeChar+"'", goqu.I(column), pattern))
+	}
+
+	return goqu.Or(conditions...)
+}
@@ -0,0 +1,96 @@
+package customstore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordQueryMemoAndSearchText(t *testing.T) {
+	db := InitDB("test_data_store_record_query_search_text.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_query_search_text",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []struct {
+		memo    string
+		payload string
+		metas   map[string]string
+	}{
+		{"Invoice for ACME", `{"customer":"Globex"}`, map[string]string{}},
+		{"Reminder", `{"customer":"Acme Corp"}`, map[string]string{}},
+		{"Discount 50% applied", `{}`, map[string]string{"source": "acme-import"}},
+		{"Unrelated", `{}`, map[string]string{}},
+	}
+
+	for _, data := range records {
+		record := customstore.NewRecord("note")
+		record.SetMemo(data.memo)
+		record.SetPayload(data.payload)
+		if err := record.SetMetas(data.metas); err != nil {
+			t.Fatalf("SetMetas failed: %v", err)
+		}
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		query         customstore.RecordQueryInterface
+		expectedCount int
+	}{
+		{"MemoLike", customstore.RecordQuery().SetMemoLike("invoice%"), 1},
+		{"MemoLikeCaseInsensitive", customstore.RecordQuery().SetMemoLike("%acme%"), 1},
+		{"MemoEquals", customstore.RecordQuery().SetMemoEquals("Reminder"), 1},
+		{"MemoEqualsNoMatch", customstore.RecordQuery().SetMemoEquals("reminder!"), 0},
+		{"SearchText", customstore.RecordQuery().SetSearchText("ACME"), 3},
+		{"SearchTextWildcardIsLiteral", customstore.RecordQuery().SetSearchText("50%"), 1},
+		{"SearchTextUnderscoreIsLiteral", customstore.RecordQuery().SetSearchText("acme_"), 0},
+		{"SearchTextEmpty", customstore.RecordQuery().SetSearchText(" "), 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := store.RecordList(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("RecordList failed: %v", err)
+			}
+
+			if len(list) != tc.expectedCount {
+				t.Fatalf("Expected %d records, but got %d", tc.expectedCount, len(list))
+			}
+		})
+	}
+
+	for _, driver := range []string{"mysql", "postgres"} {
+		t.Run("Dialect"+driver, func(t *testing.T) {
+			q, _, err := customstore.RecordQuery().
+				SetSearchText("acme").
+				ToSelectDataset(driver, "data")
+
+			if err != nil {
+				t.Fatalf("ToSelectDataset failed: %v", err)
+			}
+
+			sqlStr, _, err := q.Prepared(true).ToSQL()
+			if err != nil {
+				t.Fatalf("ToSQL failed: %v", err)
+			}
+
+			if !strings.Contains(sqlStr, "LOWER(") || !strings.Contains(sqlStr, "ESCAPE '!'") {
+				t.Fatalf("Expected a case insensitive, escaped LIKE, but got %q", sqlStr)
+			}
+		})
+	}
+}
@@ -11,10 +11,19 @@ type RecordInterface interface {
 
 	IsSoftDeleted() bool
 
+	// IsExpired returns true if the record has an expiry in the past
+	IsExpired() bool
+
 	CreatedAt() string
 	CreatedAtCarbon() *carbon.Carbon
 	SetCreatedAt(createdAt string)
 
+	// ExpiresAt requires expiry to be enabled on the store, which otherwise
+	// refuses to write it. A record without it never expires
+	ExpiresAt() string
+	ExpiresAtCarbon() *carbon.Carbon
+	SetExpiresAt(expiresAt string)
+
 	ID() string
 	SetID(id string)
 
@@ -46,4 +55,12 @@ type RecordInterface interface {
 	UpdatedAt() string
 	UpdatedAtCarbon() *carbon.Carbon
 	SetUpdatedAt(updatedAt string)
+
+	// Version returns the optimistic concurrency version, 0 if not versioned
+	Version() int
+	SetVersion(version int)
+
+	// PayloadVersion returns the version of the payload shape, 0 if not versioned
+	PayloadVersion() int
+	SetPayloadVersion(payloadVersion int)
 }
@@ -2,6 +2,7 @@ package customstore
 
 import (
 	"errors"
+	"slices"
 	"strings"
 
 	"github.com/doug-martin/goqu/v9"
@@ -17,6 +18,22 @@ type RecordQueryInterface interface {
 	IsSoftDeletedIncluded() bool
 	SetSoftDeletedIncluded(softDeletedIncluded bool) RecordQueryInterface
 
+	// SetAsOf selects the records as they were at the instant (a datetime
+	// string, time.Time or carbon.Carbon), including the records since
+	// changed or deleted. Requires history to be enabled on the store
+	IsAsOfSet() bool
+	GetAsOf() string
+	SetAsOf(asOf any) RecordQueryInterface
+
+	// SetExpiredIncluded includes the expired records, which are excluded
+	// by default by the stores with expiry enabled
+	IsExpiredIncluded() bool
+	SetExpiredIncluded(expiredIncluded bool) RecordQueryInterface
+
+	// SetOnlySoftDeleted selects the soft deleted records only (the "trash")
+	IsOnlySoftDeleted() bool
+	SetOnlySoftDeleted(onlySoftDeleted bool) RecordQueryInterface
+
 	SetColumns(columns []string) RecordQueryInterface
 	GetColumns() []string
 
@@ -27,10 +44,74 @@ type RecordQueryInterface interface {
 	GetID() string
 	SetID(id string) RecordQueryInterface
 
+	// SetIDIn matches the records with any of the IDs, bound as one
+	// parameter each. A list longer than the parameter limit of the database
+	// (999 on older SQLite, 2100 on MSSQL) fails, apart from in
+	// RecordDeleteByQuery and RecordSoftDeleteByQuery without a limit or an
+	// offset, which split it. Use RecordDeleteByIDs for large sets of IDs
+	IsIDInSet() bool
+	GetIDIn() []string
+	SetIDIn(ids []string) RecordQueryInterface
+
+	IsIDNotInSet() bool
+	GetIDNotIn() []string
+	SetIDNotIn(ids []string) RecordQueryInterface
+
 	IsTypeSet() bool
 	GetType() string
 	SetType(recordType string) RecordQueryInterface
 
+	IsTypeInSet() bool
+	GetTypeIn() []string
+	SetTypeIn(recordTypes []string) RecordQueryInterface
+
+	IsTypeNotInSet() bool
+	GetTypeNotIn() []string
+	SetTypeNotIn(recordTypes []string) RecordQueryInterface
+
+	// SetMemoLike matches the memo against a LIKE pattern (i.e. "%invoice%"),
+	// case insensitively on all databases
+	IsMemoLikeSet() bool
+	GetMemoLike() string
+	SetMemoLike(pattern string) RecordQueryInterface
+
+	IsMemoEqualsSet() bool
+	GetMemoEquals() string
+	SetMemoEquals(memo string) RecordQueryInterface
+
+	// SetSearchText matches records containing the text in the memo,
+	// the payload or the metas, case insensitively on all databases
+	IsSearchTextSet() bool
+	GetSearchText() string
+	SetSearchText(text string) RecordQueryInterface
+
+	// Date range filters accept a datetime string, time.Time or carbon.Carbon,
+	// normalised to a UTC datetime string
+	IsCreatedAtGteSet() bool
+	GetCreatedAtGte() string
+	SetCreatedAtGte(createdAtGte any) RecordQueryInterface
+
+	IsCreatedAtLteSet() bool
+	GetCreatedAtLte() string
+	SetCreatedAtLte(createdAtLte any) RecordQueryInterface
+
+	IsUpdatedAtGteSet() bool
+	GetUpdatedAtGte() string
+	SetUpdatedAtGte(updatedAtGte any) RecordQueryInterface
+
+	IsUpdatedAtLteSet() bool
+	GetUpdatedAtLte() string
+	SetUpdatedAtLte(updatedAtLte any) RecordQueryInterface
+
+	// The soft deleted date range filters select soft deleted records only
+	IsSoftDeletedAtGteSet() bool
+	GetSoftDeletedAtGte() string
+	SetSoftDeletedAtGte(softDeletedAtGte any) RecordQueryInterface
+
+	IsSoftDeletedAtLteSet() bool
+	GetSoftDeletedAtLte() string
+	SetSoftDeletedAtLte(softDeletedAtLte any) RecordQueryInterface
+
 	IsLimitSet() bool
 	GetLimit() int
 	SetLimit(limit int) RecordQueryInterface
@@ -43,11 +124,50 @@ type RecordQueryInterface interface {
 	GetOrderBy() string
 	SetOrderBy(orderBy string) RecordQueryInterface
 
+	IsSortOrderSet() bool
+	GetSortOrder() string
+	SetSortOrder(sortOrder string) RecordQueryInterface
+
+	// AddOrderBy adds a column to order by, after the sort keys already set.
+	// An id tiebreaker is always appended, so that the order is deterministic
+	AddOrderBy(column string, direction string) RecordQueryInterface
+
+	// SetOrderByPayloadField orders by a payload field, i.e.
+	// SetOrderByPayloadField("price", sb.ASC, CAST_AS_NUMERIC)
+	SetOrderByPayloadField(path string, direction string, castAs string) RecordQueryInterface
+
+	// Keyset pagination, an empty cursor starts from the first page
+	IsCursorSet() bool
+	GetCursor() string
+	SetCursor(cursor string) RecordQueryInterface
+
+	// NextCursor returns the cursor pointing after the record,
+	// based on the sort keys of the query
+	NextCursor(record RecordInterface) (string, error)
+
 	// Payload search methods
 	AddPayloadSearch(needle string) RecordQueryInterface
 	GetPayloadSearch() []string
 	AddPayloadSearchNot(needle string) RecordQueryInterface
 	GetPayloadSearchNot() []string
+
+	// AddPayloadWhere adds a condition on a payload field, i.e.
+	// AddPayloadWhere("address.city", "=", "London"). Supported operators
+	// are =, !=, <, <=, >, >=, LIKE, NOT LIKE, IN, NOT IN, IS NULL, IS NOT NULL
+	AddPayloadWhere(path string, operator string, value any) RecordQueryInterface
+
+	// AddMetaWhere adds a condition on a meta, i.e.
+	// AddMetaWhere("owner_id", "=", "123"). Meta values are stored as strings,
+	// numeric values are compared numerically. Supports the same operators
+	// as AddPayloadWhere
+	AddMetaWhere(key string, operator string, value any) RecordQueryInterface
+
+	// SetMetaExists requires the meta with the given key to be set
+	SetMetaExists(key string) RecordQueryInterface
+
+	// Where adds a filter tree, i.e. Where(Or(And(...), And(...))).
+	// Filters added by several calls must all match
+	Where(filter FilterInterface) RecordQueryInterface
 }
 
 // RecordQuery shortcut for NewRecordQuery
@@ -76,12 +196,54 @@ type recordQueryImplementation struct {
 	// id is the ID of the API record
 	id string
 
+	// isIDInSet is true if the ID IN filter is set, false otherwise
+	isIDInSet bool
+
+	// idIn is the list of IDs to match
+	idIn []string
+
+	// isIDNotInSet is true if the ID NOT IN filter is set, false otherwise
+	isIDNotInSet bool
+
+	// idNotIn is the list of IDs to exclude
+	idNotIn []string
+
 	// isTypeSet is true if the record type is set, false otherwise
 	isTypeSet bool
 
 	// recordType is the record type of the API record
 	recordType string
 
+	// isTypeInSet is true if the type IN filter is set, false otherwise
+	isTypeInSet bool
+
+	// typeIn is the list of record types to match
+	typeIn []string
+
+	// isTypeNotInSet is true if the type NOT IN filter is set, false otherwise
+	isTypeNotInSet bool
+
+	// typeNotIn is the list of record types to exclude
+	typeNotIn []string
+
+	// isMemoLikeSet is true if the memo LIKE filter is set, false otherwise
+	isMemoLikeSet bool
+
+	// memoLike is the LIKE pattern to match the memo against
+	memoLike string
+
+	// isMemoEqualsSet is true if the memo equals filter is set, false otherwise
+	isMemoEqualsSet bool
+
+	// memoEquals is the memo to match
+	memoEquals string
+
+	// isSearchTextSet is true if the search text is set, false otherwise
+	isSearchTextSet bool
+
+	// searchText is the text to search for in the memo, payload and metas
+	searchText string
+
 	// columns is the list of columns to select
 	columns []string
 
@@ -91,6 +253,18 @@ type recordQueryImplementation struct {
 	// isSoftDeletedIncluded is true if soft deleted records should be included, false otherwise
 	isSoftDeletedIncluded bool
 
+	// isAsOfSet is true if the as of instant is set, false otherwise
+	isAsOfSet bool
+
+	// asOf is the instant to select the records at, as a UTC datetime string
+	asOf string
+
+	// isExpiredIncluded is true if expired records should be included, false otherwise
+	isExpiredIncluded bool
+
+	// isOnlySoftDeleted is true if only soft deleted records should be selected, false otherwise
+	isOnlySoftDeleted bool
+
 	isLimitSet bool
 
 	// limit is the limit of the API record
@@ -107,11 +281,44 @@ type recordQueryImplementation struct {
 	// orderBy is the order by of the API record
 	orderBy string
 
+	// isSortOrderSet is true if the sort order is set, false otherwise
+	isSortOrderSet bool
+
+	// sortOrder is the direction of the order by column, sb.DESC by default
+	sortOrder string
+
+	// orderByPayloadField is the payload field to order by, if any
+	orderByPayloadField *queryOrder
+
+	// orderBys are the additional sort keys, in the order added
+	orderBys []queryOrder
+
+	// isCursorSet is true if keyset pagination is used, false otherwise
+	isCursorSet bool
+
+	// cursor is the opaque cursor token, empty for the first page
+	cursor string
+
 	// payloadSearch is the list of strings to search for in the payload
 	payloadSearch []string
 
 	// payloadSearchNot is the list of strings that should NOT be in the payload
 	payloadSearchNot []string
+
+	// dateRanges are the date range filters, keyed by column and operator
+	dateRanges map[string]string
+
+	// dateRangeErrors are the errors normalising the date range values
+	dateRangeErrors []error
+
+	// payloadWhere is the list of conditions on payload fields
+	payloadWhere []jsonFieldCondition
+
+	// metaWhere is the list of conditions on metas
+	metaWhere []jsonFieldCondition
+
+	// filters is the list of filter trees added with Where
+	filters []FilterInterface
 }
 
 func (o *recordQueryImplementation) Validate() error {
@@ -123,6 +330,48 @@ func (o *recordQueryImplementation) Validate() error {
 		return errors.New("type is required")
 	}
 
+	if len(o.dateRangeErrors) > 0 {
+		return errors.Join(o.dateRangeErrors...)
+	}
+
+	for _, condition := range slices.Concat(o.payloadWhere, o.metaWhere) {
+		if err := condition.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, filter := range o.filters {
+		if filter == nil {
+			return errors.New("filter is nil")
+		}
+
+		if err := filter.validate(); err != nil {
+			return err
+		}
+	}
+
+	if o.IsSortOrderSet() {
+		if err := validateSortDirection(o.GetSortOrder()); err != nil {
+			return err
+		}
+	}
+
+	for _, order := range o.orderKeys() {
+		if err := order.validate(); err != nil {
+			return err
+		}
+	}
+
+	if o.IsCursorSet() && o.IsOffsetSet() {
+		return errors.New("cursor and offset cannot be used together")
+	}
+
+	if o.IsCursorSet() && o.GetCursor() != "" {
+		if _, err := decodeCursor(o.GetCursor()); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -133,28 +382,39 @@ func (o *recordQueryImplementation) ToSelectDataset(driver string, table string)
 
 	q := goqu.Dialect(driver).From(table)
 
-	if o.IsSoftDeletedIncluded() {
-		return q, []any{}, nil // soft deleted sites requested specifically
-	}
-
-	// if o.IsCreatedAtGteSet() && o.IsCreatedAtLteSet() {
-	// 	q = q.Where(
-	// 		goqu.C(COLUMN_CREATED_AT).Gte(o.GetCreatedAtGte()),
-	// 		goqu.C(COLUMN_CREATED_AT).Lte(o.GetCreatedAtLte()),
-	// 	)
-	// } else if o.IsCreatedAtGteSet() {
-	// 	q = q.Where(goqu.C(COLUMN_CREATED_AT).Gte(o.GetCreatedAtGte()))
-	// } else if o.IsCreatedAtLteSet() {
-	// 	q = q.Where(goqu.C(COLUMN_CREATED_AT).Lte(o.GetCreatedAtLte()))
-	// }
+	for _, column := range []string{COLUMN_CREATED_AT, COLUMN_UPDATED_AT, COLUMN_SOFT_DELETED_AT} {
+		if value, isSet := o.dateRanges[column+">="]; isSet {
+			q = q.Where(goqu.C(column).Gte(value))
+		}
+
+		if value, isSet := o.dateRanges[column+"<="]; isSet {
+			q = q.Where(goqu.C(column).Lte(value))
+		}
+	}
 
 	if o.IsIDSet() {
 		q = q.Where(goqu.C(COLUMN_ID).Eq(o.GetID()))
 	}
 
-	// if o.IsIDInSet() {
-	// 	q = q.Where(goqu.C(COLUMN_ID).In(o.GetIDIn()))
-	// }
+	if o.IsIDInSet() {
+		q = q.Where(inCondition(COLUMN_ID, o.GetIDIn()))
+	}
+
+	if o.IsIDNotInSet() && len(o.GetIDNotIn()) > 0 {
+		q = q.Where(goqu.C(COLUMN_ID).NotIn(o.GetIDNotIn()))
+	}
+
+	if o.IsMemoLikeSet() {
+		q = q.Where(likeInsensitive(COLUMN_MEMO, o.GetMemoLike()))
+	}
+
+	if o.IsMemoEqualsSet() {
+		q = q.Where(goqu.C(COLUMN_MEMO).Eq(o.GetMemoEquals()))
+	}
+
+	if o.IsSearchTextSet() && strings.TrimSpace(o.GetSearchText()) != "" {
+		q = q.Where(searchTextCondition(o.GetSearchText()))
+	}
 
 	// if o.IsNameLikeSet() {
 	// 	q = q.Where(goqu.C(COLUMN_NAME).Like("%" + o.GetNameLike() + "%"))
@@ -168,6 +428,14 @@ func (o *recordQueryImplementation) ToSelectDataset(driver string, table string)
 	// 	q = q.Where(goqu.C(COLUMN_STATUS).In(o.GetStatusIn()))
 	// }
 
+	if o.IsTypeInSet() {
+		q = q.Where(inCondition(COLUMN_RECORD_TYPE, o.GetTypeIn()))
+	}
+
+	if o.IsTypeNotInSet() && len(o.GetTypeNotIn()) > 0 {
+		q = q.Where(goqu.C(COLUMN_RECORD_TYPE).NotIn(o.GetTypeNotIn()))
+	}
+
 	// Add payload search conditions
 	conditions := []goqu.Expression{}
 
@@ -185,6 +453,36 @@ func (o *recordQueryImplementation) ToSelectDataset(driver string, table string)
 		}
 	}
 
+	for _, condition := range slices.Concat(o.payloadWhere, o.metaWhere) {
+		expression, err := condition.toExpression(driver)
+		if err != nil {
+			return nil, []any{}, err
+		}
+		conditions = append(conditions, expression)
+	}
+
+	for _, filter := range o.filters {
+		expression, err := filter.toExpression(driver)
+		if err != nil {
+			return nil, []any{}, err
+		}
+		conditions = append(conditions, expression)
+	}
+
+	if o.IsCursorSet() && o.GetCursor() != "" {
+		cursor, err := decodeCursor(o.GetCursor())
+		if err != nil {
+			return nil, []any{}, err
+		}
+
+		expression, err := cursorCondition(driver, o.orderKeys(), cursor)
+		if err != nil {
+			return nil, []any{}, err
+		}
+
+		conditions = append(conditions, expression)
+	}
+
 	if len(conditions) > 0 {
 		q = q.Where(goqu.And(conditions...))
 	}
@@ -203,17 +501,8 @@ func (o *recordQueryImplementation) ToSelectDataset(driver string, table string)
 		}
 	}
 
-	sortOrder := sb.DESC
-	// if o.IsSortOrderSet() {
-	// 	sortOrder = o.GetSortOrder()
-	// }
-
-	if o.IsOrderBySet() {
-		if strings.EqualFold(sortOrder, sb.ASC) {
-			q = q.Order(goqu.I(o.GetOrderBy()).Asc())
-		} else {
-			q = q.Order(goqu.I(o.GetOrderBy()).Desc())
-		}
+	for _, order := range o.orderKeys() {
+		q = q.OrderAppend(order.toOrderedExpression(driver))
 	}
 
 	columns = []any{}
@@ -222,18 +511,27 @@ func (o *recordQueryImplementation) ToSelectDataset(driver string, table string)
 		columns = append(columns, column)
 	}
 
-	if o.IsSoftDeletedIncluded() {
-		return q, columns, nil // soft deleted sites requested specifically
+	if o.IsTypeSet() {
+		q = q.Where(goqu.C(COLUMN_RECORD_TYPE).Eq(o.GetType()))
 	}
 
-	softDeleted := goqu.C(COLUMN_SOFT_DELETED_AT).
-		Gt(carbon.Now(carbon.UTC).ToDateTimeString())
+	now := carbon.Now(carbon.UTC).ToDateTimeString()
 
-	if o.IsTypeSet() {
-		q = q.Where(goqu.C(COLUMN_RECORD_TYPE).Eq(o.GetType()))
+	if o.IsAsOfSet() {
+		now = o.GetAsOf() // soft deleted as of the instant
 	}
 
-	return q.Where(softDeleted), columns, nil
+	// filtering by deletion date implies soft deleted records only,
+	// as the ones not deleted have soft_deleted_at in the far future
+	if o.IsOnlySoftDeleted() || o.IsSoftDeletedAtGteSet() || o.IsSoftDeletedAtLteSet() {
+		return q.Where(goqu.C(COLUMN_SOFT_DELETED_AT).Lte(now)), columns, nil
+	}
+
+	if o.IsSoftDeletedIncluded() {
+		return q, columns, nil // soft deleted records requested specifically
+	}
+
+	return q.Where(goqu.C(COLUMN_SOFT_DELETED_AT).Gt(now)), columns, nil
 }
 
 func (o *recordQueryImplementation) SetColumns(columns []string) RecordQueryInterface {
@@ -274,6 +572,115 @@ func (o *recordQueryImplementation) SetID(id string) RecordQueryInterface {
 	return o
 }
 
+func (o *recordQueryImplementation) IsIDInSet() bool {
+	return o.isIDInSet
+}
+
+func (o *recordQueryImplementation) GetIDIn() []string {
+	return o.idIn
+}
+
+func (o *recordQueryImplementation) SetIDIn(ids []string) RecordQueryInterface {
+	o.isIDInSet = true
+	o.idIn = ids
+	return o
+}
+
+func (o *recordQueryImplementation) IsIDNotInSet() bool {
+	return o.isIDNotInSet
+}
+
+func (o *recordQueryImplementation) GetIDNotIn() []string {
+	return o.idNotIn
+}
+
+func (o *recordQueryImplementation) SetIDNotIn(ids []string) RecordQueryInterface {
+	o.isIDNotInSet = true
+	o.idNotIn = ids
+	return o
+}
+
+func (o *recordQueryImplementation) IsMemoLikeSet() bool {
+	return o.isMemoLikeSet
+}
+
+func (o *recordQueryImplementation) GetMemoLike() string {
+	return o.memoLike
+}
+
+func (o *recordQueryImplementation) SetMemoLike(pattern string) RecordQueryInterface {
+	o.isMemoLikeSet = true
+	o.memoLike = pattern
+	return o
+}
+
+func (o *recordQueryImplementation) IsMemoEqualsSet() bool {
+	return o.isMemoEqualsSet
+}
+
+func (o *recordQueryImplementation) GetMemoEquals() string {
+	return o.memoEquals
+}
+
+func (o *recordQueryImplementation) SetMemoEquals(memo string) RecordQueryInterface {
+	o.isMemoEqualsSet = true
+	o.memoEquals = memo
+	return o
+}
+
+func (o *recordQueryImplementation) IsSearchTextSet() bool {
+	return o.isSearchTextSet
+}
+
+func (o *recordQueryImplementation) GetSearchText() string {
+	return o.searchText
+}
+
+func (o *recordQueryImplementation) SetSearchText(text string) RecordQueryInterface {
+	o.isSearchTextSet = true
+	o.searchText = text
+	return o
+}
+
+func (o *recordQueryImplementation) IsAsOfSet() bool {
+	return o.isAsOfSet
+}
+
+func (o *recordQueryImplementation) GetAsOf() string {
+	return o.asOf
+}
+
+func (o *recordQueryImplementation) SetAsOf(asOf any) RecordQueryInterface {
+	dateTime, err := toDateTimeString(asOf)
+
+	if err != nil {
+		o.dateRangeErrors = append(o.dateRangeErrors, errors.New("as of: "+err.Error()))
+		return o
+	}
+
+	o.isAsOfSet = true
+	o.asOf = dateTime
+	return o
+}
+
+func (o *recordQueryImplementation) IsExpiredIncluded() bool {
+	return o.isExpiredIncluded
+}
+
+func (o *recordQueryImplementation) SetExpiredIncluded(expiredIncluded bool) RecordQueryInterface {
+	o.isExpiredIncluded = expiredIncluded
+	return o
+}
+
+func (o *recordQueryImplementation) IsOnlySoftDeleted() bool {
+	return o.isOnlySoftDeleted
+}
+
+func (o *recordQueryImplementation) SetOnlySoftDeleted(onlySoftDeleted bool) RecordQueryInterface {
+	o.isOnlySoftDeleted = onlySoftDeleted
+	return o
+}
+
 func (o *recordQueryImplementation) IsSoftDeletedIncluded() bool {
 	return o.isSoftDeletedIncluded
 }
@@ -283,6 +690,100 @@ func (o *recordQueryImplementation) SetSoftDeletedIncluded(softDeletedIncluded b
 	return o
 }
 
+func (o *recordQueryImplementation) IsCreatedAtGteSet() bool {
+	return o.isDateRangeSet(COLUMN_CREATED_AT, ">=")
+}
+
+func (o *recordQueryImplementation) GetCreatedAtGte() string {
+	return o.dateRanges[COLUMN_CREATED_AT+">="]
+}
+
+func (o *recordQueryImplementation) SetCreatedAtGte(createdAtGte any) RecordQueryInterface {
+	return o.setDateRange(COLUMN_CREATED_AT, ">=", createdAtGte)
+}
+
+func (o *recordQueryImplementation) IsCreatedAtLteSet() bool {
+	return o.isDateRangeSet(COLUMN_CREATED_AT, "<=")
+}
+
+func (o *recordQueryImplementation) GetCreatedAtLte() string {
+	return o.dateRanges[COLUMN_CREATED_AT+"<="]
+}
+
+func (o *recordQueryImplementation) SetCreatedAtLte(createdAtLte any) RecordQueryInterface {
+	return o.setDateRange(COLUMN_CREATED_AT, "<=", createdAtLte)
+}
+
+func (o *recordQueryImplementation) IsUpdatedAtGteSet() bool {
+	return o.isDateRangeSet(COLUMN_UPDATED_AT, ">=")
+}
+
+func (o *recordQueryImplementation) GetUpdatedAtGte() string {
+	return o.dateRanges[COLUMN_UPDATED_AT+">="]
+}
+
+func (o *recordQueryImplementation) SetUpdatedAtGte(updatedAtGte any) RecordQueryInterface {
+	return o.setDateRange(COLUMN_UPDATED_AT, ">=", updatedAtGte)
+}
+
+func (o *recordQueryImplementation) IsUpdatedAtLteSet() bool {
+	return o.isDateRangeSet(COLUMN_UPDATED_AT, "<=")
+}
+
+func (o *recordQueryImplementation) GetUpdatedAtLte() string {
+	return o.dateRanges[COLUMN_UPDATED_AT+"<="]
+}
+
+func (o *recordQueryImplementation) SetUpdatedAtLte(updatedAtLte any) RecordQueryInterface {
+	return o.setDateRange(COLUMN_UPDATED_AT, "<=", updatedAtLte)
+}
+
+func (o *recordQueryImplementation) IsSoftDeletedAtGteSet() bool {
+	return o.isDateRangeSet(COLUMN_SOFT_DELETED_AT, ">=")
+}
+
+func (o *recordQueryImplementation) GetSoftDeletedAtGte() string {
+	return o.dateRanges[COLUMN_SOFT_DELETED_AT+">="]
+}
+
+func (o *recordQueryImplementation) SetSoftDeletedAtGte(softDeletedAtGte any) RecordQueryInterface {
+	return o.setDateRange(COLUMN_SOFT_DELETED_AT, ">=", softDeletedAtGte)
+}
+
+func (o *recordQueryImplementation) IsSoftDeletedAtLteSet() bool {
+	return o.isDateRangeSet(COLUMN_SOFT_DELETED_AT, "<=")
+}
+
+func (o *recordQueryImplementation) GetSoftDeletedAtLte() string {
+	return o.dateRanges[COLUMN_SOFT_DELETED_AT+"<="]
+}
+
+func (o *recordQueryImplementation) SetSoftDeletedAtLte(softDeletedAtLte any) RecordQueryInterface {
+	return o.setDateRange(COLUMN_SOFT_DELETED_AT, "<=", softDeletedAtLte)
+}
+
+func (o *recordQueryImplementation) isDateRangeSet(column string, operator string) bool {
+	_, isSet := o.dateRanges[column+operator]
+	return isSet
+}
+
+func (o *recordQueryImplementation) setDateRange(column string, operator string, value any) RecordQueryInterface {
+	dateTime, err := toDateTimeString(value)
+
+	if err != nil {
+		o.dateRangeErrors = append(o.dateRangeErrors, errors.New(column+" "+operator+": "+err.Error()))
+		return o
+	}
+
+	if o.dateRanges == nil {
+		o.dateRanges = map[string]string{}
+	}
+
+	o.dateRanges[column+operator] = dateTime
+
+	return o
+}
+
 func (o *recordQueryImplementation) IsLimitSet() bool {
 	return o.isLimitSet
 }
@@ -325,6 +826,77 @@ func (o *recordQueryImplementation) SetOrderBy(orderBy string) RecordQueryInterf
 	return o
 }
 
+func (o *recordQueryImplementation) IsSortOrderSet() bool {
+	return o.isSortOrderSet
+}
+
+func (o *recordQueryImplementation) GetSortOrder() string {
+	if !o.isSortOrderSet {
+		return sb.DESC
+	}
+	return o.sortOrder
+}
+
+func (o *recordQueryImplementation) SetSortOrder(sortOrder string) RecordQueryInterface {
+	o.isSortOrderSet = true
+	o.sortOrder = sortOrder
+	return o
+}
+
+func (o *recordQueryImplementation) AddOrderBy(column string, direction string) RecordQueryInterface {
+	o.orderBys = append(o.orderBys, queryOrder{
+		column:    column,
+		direction: direction,
+	})
+	return o
+}
+
+func (o *recordQueryImplementation) SetOrderByPayloadField(path string, direction string, castAs string) RecordQueryInterface {
+	if castAs == "" {
+		castAs = CAST_AS_TEXT
+	}
+
+	o.orderByPayloadField = &queryOrder{
+		path:      path,
+		direction: direction,
+		castAs:    castAs,
+	}
+
+	return o
+}
+
+func (o *recordQueryImplementation) IsCursorSet() bool {
+	return o.isCursorSet
+}
+
+func (o *recordQueryImplementation) GetCursor() string {
+	return o.cursor
+}
+
+func (o *recordQueryImplementation) SetCursor(cursor string) RecordQueryInterface {
+	o.isCursorSet = true
+	o.cursor = cursor
+	return o
+}
+
+func (o *recordQueryImplementation) NextCursor(record RecordInterface) (string, error) {
+	if record == nil {
+		return "", errors.New("record is nil")
+	}
+
+	cursor := queryCursor{Values: []any{}}
+
+	for _, key := range o.orderKeys() {
+		value, err := cursorValue(key, record)
+		if err != nil {
+			return "", err
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+
+	return encodeCursor(cursor)
+}
+
 func (o *recordQueryImplementation) IsTypeSet() bool {
 	return o.isTypeSet
 }
@@ -339,6 +911,34 @@ func (o *recordQueryImplementation) SetType(recordType string) RecordQueryInterf
 	return o
 }
 
+func (o *recordQueryImplementation) IsTypeInSet() bool {
+	return o.isTypeInSet
+}
+
+func (o *recordQueryImplementation) GetTypeIn() []string {
+	return o.typeIn
+}
+
+func (o *recordQueryImplementation) SetTypeIn(recordTypes []string) RecordQueryInterface {
+	o.isTypeInSet = true
+	o.typeIn = recordTypes
+	return o
+}
+
+func (o *recordQueryImplementation) IsTypeNotInSet() bool {
+	return o.isTypeNotInSet
+}
+
+func (o *recordQueryImplementation) GetTypeNotIn() []string {
+	return o.typeNotIn
+}
+
+func (o *recordQueryImplementation) SetTypeNotIn(recordTypes []string) RecordQueryInterface {
+	o.isTypeNotInSet = true
+	o.typeNotIn = recordTypes
+	return o
+}
+
 func (o *recordQueryImplementation) AddPayloadSearch(needle string) RecordQueryInterface {
 	if o.payloadSearch == nil {
 		o.payloadSearch = []string{}
@@ -362,3 +962,82 @@ func (o *recordQueryImplementation) AddPayloadSearchNot(needle string) RecordQue
 func (o *recordQueryImplementation) GetPayloadSearchNot() []string {
 	return o.payloadSearchNot
 }
+
+func (o *recordQueryImplementation) AddPayloadWhere(path string, operator string, value any) RecordQueryInterface {
+	o.payloadWhere = append(o.payloadWhere, jsonFieldCondition{
+		column:   COLUMN_PAYLOAD,
+		path:     path,
+		operator: operator,
+		value:    value,
+	})
+	return o
+}
+
+func (o *recordQueryImplementation) AddMetaWhere(key string, operator string, value any) RecordQueryInterface {
+	o.metaWhere = append(o.metaWhere, jsonFieldCondition{
+		column:   COLUMN_METAS,
+		path:     key,
+		operator: operator,
+		value:    value,
+	})
+	return o
+}
+
+func (o *recordQueryImplementation) SetMetaExists(key string) RecordQueryInterface {
+	return o.AddMetaWhere(key, "IS NOT NULL", nil)
+}
+
+func (o *recordQueryImplementation) Where(filter FilterInterface) RecordQueryInterface {
+	o.filters = append(o.filters, filter)
+	return o
+}
+
+// orderKeys returns the sort keys in order: the order by column,
+// the payload field, the added columns, and finally the id tiebreaker
+func (o *recordQueryImplementation) orderKeys() []queryOrder {
+	keys := []queryOrder{}
+
+	if o.IsOrderBySet() {
+		keys = append(keys, queryOrder{
+			column:    o.GetOrderBy(),
+			direction: o.GetSortOrder(),
+		})
+	}
+
+	if o.orderByPayloadField != nil {
+		keys = append(keys, *o.orderByPayloadField)
+	}
+
+	keys = append(keys, o.orderBys...)
+
+	if len(keys) < 1 && o.IsCursorSet() {
+		return []queryOrder{{column: COLUMN_ID, direction: sb.ASC}} // keyset pagination requires an order
+	}
+
+	if len(keys) < 1 {
+		return keys
+	}
+
+	for _, key := range keys {
+		if key.column == COLUMN_ID {
+			return keys // already unique
+		}
+	}
+
+	// records sharing the same sort values (i.e. timestamps) are
+	// ordered by id, so pagination is deterministic
+	return append(keys, queryOrder{
+		column:    COLUMN_ID,
+		direction: keys[0].direction,
+	})
+}
+
+// inCondition returns a column IN condition, matching nothing for an empty
+// list (an empty IN clause is not valid SQL)
+func inCondition(column string, values []string) goqu.Expression {
+	if len(values) < 1 {
+		return goqu.L("1 = 0")
+	}
+
+	return goqu.C(column).In(values)
+}
@@ -212,6 +212,17 @@ func TestMemo(t *testing.T) {
 	}
 }
 
+func TestVersion(t *testing.T) {
+	record := customstore.NewRecord("test")
+	if record.Version() != 0 {
+		t.Errorf("Expected Version 0 for a new record, but got %d", record.Version())
+	}
+	record.SetVersion(7)
+	if record.Version() != 7 {
+		t.Errorf("Expected Version 7, but got %d", record.Version())
+	}
+}
+
 func TestMetas(t *testing.T) {
 	record := customstore.NewRecord("test")
 
@@ -4,13 +4,110 @@ import "github.com/gouniverse/sb"
 
 // SqlCreateUserTable returns a SQL string for creating the user table
 func (store *storeImplementation) SqlCreateTable() string {
-	sql := sb.NewBuilder(sb.DatabaseDriverName(store.db)).
+	builder := sb.NewBuilder(sb.DatabaseDriverName(store.db)).
 		Table(store.tableName).
 		Column(sb.Column{
 			Name:       COLUMN_ID,
 			Type:       sb.COLUMN_TYPE_STRING,
 			Length:     40,
 			PrimaryKey: true,
+		})
+
+	for _, column := range store.sqlRecordColumns() {
+		builder = builder.Column(column)
+	}
+
+	return builder.CreateIfNotExists()
+}
+
+// SqlCreateHistoryTable returns a SQL string for creating the history table,
+// which keeps the previous states of the records
+func (store *storeImplementation) SqlCreateHistoryTable() string {
+	builder := sb.NewBuilder(sb.DatabaseDriverName(store.db)).
+		Table(store.historyTableName()).
+		Column(sb.Column{
+			Name:       COLUMN_HISTORY_ID,
+			Type:       sb.COLUMN_TYPE_STRING,
+			Length:     40,
+			PrimaryKey: true,
+		}).
+		Column(sb.Column{
+			Name:   COLUMN_ID,
+			Type:   sb.COLUMN_TYPE_STRING,
+			Length: 40,
+		}).
+		Column(sb.Column{
+			Name: COLUMN_REVISION,
+			Type: sb.COLUMN_TYPE_INTEGER,
+		}).
+		Column(sb.Column{
+			Name:   COLUMN_OPERATION,
+			Type:   sb.COLUMN_TYPE_STRING,
+			Length: 20,
+		}).
+		Column(sb.Column{
+			Name: COLUMN_CHANGED_AT,
+			Type: sb.COLUMN_TYPE_DATETIME,
+		})
+
+	for _, column := range store.sqlRecordColumns() {
+		builder = builder.Column(column)
+	}
+
+	return builder.CreateIfNotExists()
+}
+
+// SqlCreateHistoryIndex returns a SQL string for creating the unique index
+// on the ID and the revision of the history table, so concurrent changes
+// numbering the same revision fail rather than keep duplicates. MySQL has
+// no IF NOT EXISTS for indexes, AutoMigrate checks it exists instead
+func (store *storeImplementation) SqlCreateHistoryIndex() string {
+	driver := sb.DatabaseDriverName(store.db)
+
+	quote := func(name string) string {
+		switch driver {
+		case sb.DIALECT_MYSQL:
+			return "`" + name + "`"
+		case sb.DIALECT_MSSQL:
+			return "[" + name + "]"
+		default:
+			return `"` + name + `"`
+		}
+	}
+
+	indexName := store.historyIndexName()
+	columns := quote(COLUMN_ID) + "," + quote(COLUMN_REVISION)
+
+	switch driver {
+	case sb.DIALECT_MYSQL:
+		return "CREATE UNIQUE INDEX " + quote(indexName) + " ON " + quote(store.historyTableName()) + " (" + columns + ");"
+	case sb.DIALECT_MSSQL:
+		return "IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = '" + indexName + "') " +
+			"CREATE UNIQUE INDEX " + quote(indexName) + " ON " + quote(store.historyTableName()) + " (" + columns + ");"
+	default:
+		return "CREATE UNIQUE INDEX IF NOT EXISTS " + quote(indexName) + " ON " + quote(store.historyTableName()) + " (" + columns + ");"
+	}
+}
+
+// SqlCreateChangesTable returns a SQL string for creating the change feed
+// table
+func (store *storeImplementation) SqlCreateChangesTable() string {
+	return sb.NewBuilder(sb.DatabaseDriverName(store.db)).
+		Table(store.changesTableName()).
+		Column(sb.Column{
+			Name:       COLUMN_SEQUENCE,
+			Type:       sb.COLUMN_TYPE_INTEGER,
+			PrimaryKey: true,
+		}).
+		Column(sb.Column{
+			Name:   COLUMN_OPERATION,
+			Type:   sb.COLUMN_TYPE_STRING,
+			Length: 20,
+		}).
+		Column(sb.Column{
+			Name:   COLUMN_RECORD_ID,
+			Type:   sb.COLUMN_TYPE_STRING,
+			Length: 40,
 		}).
 		Column(sb.Column{
 			Name:   COLUMN_RECORD_TYPE,
@@ -18,31 +115,107 @@ func (store *storeImplementation) SqlCreateTable() string {
 			Length: 100,
 		}).
 		Column(sb.Column{
-			Name: COLUMN_PAYLOAD,
+			Name: COLUMN_CHANGED,
 			Type: sb.COLUMN_TYPE_LONGTEXT,
 		}).
 		Column(sb.Column{
-			Name: COLUMN_METAS,
-			Type: sb.COLUMN_TYPE_TEXT,
+			Name: COLUMN_CHANGED_AT,
+			Type: sb.COLUMN_TYPE_DATETIME,
 		}).
+		CreateIfNotExists()
+}
+
+// SqlCreateChangesSequenceTable returns a SQL string for creating the table
+// holding the counter of the change sequence, in a single row
+func (store *storeImplementation) SqlCreateChangesSequenceTable() string {
+	return sb.NewBuilder(sb.DatabaseDriverName(store.db)).
+		Table(store.changesSequenceTableName()).
 		Column(sb.Column{
-			Name: COLUMN_MEMO,
-			Type: sb.COLUMN_TYPE_TEXT,
+			Name: COLUMN_SEQUENCE,
+			Type: sb.COLUMN_TYPE_INTEGER,
 		}).
+		CreateIfNotExists()
+}
+
+// SqlCreateCheckpointsTable returns a SQL string for creating the table
+// keeping the last sequence each consumer of the change feed processed
+func (store *storeImplementation) SqlCreateCheckpointsTable() string {
+	return sb.NewBuilder(sb.DatabaseDriverName(store.db)).
+		Table(store.checkpointsTableName()).
 		Column(sb.Column{
-			Name: COLUMN_CREATED_AT,
-			Type: sb.COLUMN_TYPE_DATETIME,
+			Name:       COLUMN_CONSUMER,
+			Type:       sb.COLUMN_TYPE_STRING,
+			Length:     100,
+			PrimaryKey: true,
+		}).
+		Column(sb.Column{
+			Name: COLUMN_SEQUENCE,
+			Type: sb.COLUMN_TYPE_INTEGER,
 		}).
 		Column(sb.Column{
 			Name: COLUMN_UPDATED_AT,
 			Type: sb.COLUMN_TYPE_DATETIME,
 		}).
-		Column(sb.Column{
+		CreateIfNotExists()
+}
+
+// sqlRecordColumns returns the columns of a record, apart from the ID,
+// depending on the options enabled
+func (store *storeImplementation) sqlRecordColumns() []sb.Column {
+	columns := []sb.Column{
+		{
+			Name:   COLUMN_RECORD_TYPE,
+			Type:   sb.COLUMN_TYPE_STRING,
+			Length: 100,
+		},
+		{
+			Name: COLUMN_PAYLOAD,
+			Type: sb.COLUMN_TYPE_LONGTEXT,
+		},
+		{
+			Name: COLUMN_METAS,
+			Type: sb.COLUMN_TYPE_TEXT,
+		},
+		{
+			Name: COLUMN_MEMO,
+			Type: sb.COLUMN_TYPE_TEXT,
+		},
+		{
+			Name: COLUMN_CREATED_AT,
+			Type: sb.COLUMN_TYPE_DATETIME,
+		},
+		{
+			Name: COLUMN_UPDATED_AT,
+			Type: sb.COLUMN_TYPE_DATETIME,
+		},
+		{
 			Name:     COLUMN_SOFT_DELETED_AT,
 			Type:     sb.COLUMN_TYPE_DATETIME,
 			Nullable: true,
-		}).
-		CreateIfNotExists()
+		},
+	}
+
+	if store.versioningEnabled {
+		columns = append(columns, sb.Column{
+			Name: COLUMN_VERSION,
+			Type: sb.COLUMN_TYPE_INTEGER,
+		})
+	}
+
+	if store.expiryEnabled {
+		columns = append(columns, sb.Column{
+			Name:     COLUMN_EXPIRES_AT,
+			Type:     sb.COLUMN_TYPE_DATETIME,
+			Nullable: true,
+		})
+	}
+
+	if store.payloadVersioningEnabled {
+		columns = append(columns, sb.Column{
+			Name: COLUMN_PAYLOAD_VERSION,
+			Type: sb.COLUMN_TYPE_INTEGER,
+		})
+	}
 
-	return sql
+	return columns
 }
@@ -0,0 +1,96 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// RecordFindByIDAsOf returns a record by ID as it was at the instant
+// (a datetime string, time.Time or carbon.Carbon), even if it was changed
+// or deleted since. Requires history to be enabled
+func (st *storeImplementation) RecordFindByIDAsOf(ctx context.Context, id string, asOf any) (RecordInterface, error) {
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if id == "" {
+		return nil, errors.New("record id is empty")
+	}
+
+	list, err := st.RecordList(ctx, RecordQuery().
+		SetID(id).
+		SetAsOf(asOf).
+		SetLimit(1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) > 0 {
+		return list[0], nil
+	}
+
+	return nil, nil
+}
+
+// asOfSource returns the records as they were at the instant, to select
+// from instead of the table. The state of a record changed after the
+// instant is the snapshot taken by its first change after it, otherwise
+// it is the current row. A record recreated after the instant, its first
+// change being HISTORY_OPERATION_CREATE, did not exist at it.
+//
+// The union is written as a literal, as goqu wraps the second select of
+// a union in parentheses, which SQLite does not accept.
+func (st *storeImplementation) asOfSource(asOf string) exp.LiteralExpression {
+	columns := st.recordColumnNames()
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+
+	args := []any{}
+
+	// the snapshots taken by the first change after the instant
+	for _, column := range columns {
+		args = append(args, goqu.I("h."+column))
+	}
+	args = append(args,
+		goqu.T(st.historyTableName()), goqu.I("h"),
+		goqu.I("h."+COLUMN_CHANGED_AT), asOf,
+		goqu.I("h."+COLUMN_OPERATION), HISTORY_OPERATION_CREATE,
+		goqu.I("h."+COLUMN_REVISION),
+		goqu.I("f."+COLUMN_REVISION), goqu.T(st.historyTableName()), goqu.I("f"),
+		goqu.I("f."+COLUMN_ID), goqu.I("h."+COLUMN_ID),
+		goqu.I("f."+COLUMN_CHANGED_AT), asOf,
+	)
+
+	// the current rows, not changed after the instant
+	for _, column := range columns {
+		args = append(args, goqu.I("t."+column))
+	}
+	args = append(args,
+		goqu.T(st.tableName), goqu.I("t"),
+		goqu.T(st.historyTableName()), goqu.I("c"),
+		goqu.I("c."+COLUMN_ID), goqu.I("t."+COLUMN_ID),
+		goqu.I("c."+COLUMN_CHANGED_AT), asOf,
+	)
+
+	sql := "(SELECT " + placeholders + " FROM ? AS ?" +
+		" WHERE ? > ? AND ? <> ? AND ? = (SELECT MIN(?) FROM ? AS ? WHERE ? = ? AND ? > ?)" +
+		" UNION ALL SELECT " + placeholders + " FROM ? AS ?" +
+		" WHERE NOT EXISTS (SELECT 1 FROM ? AS ? WHERE ? = ? AND ? > ?))"
+
+	return goqu.L(sql, args...)
+}
+
+// recordColumnNames returns the names of the columns of a record
+func (st *storeImplementation) recordColumnNames() []string {
+	names := []string{COLUMN_ID}
+
+	for _, column := range st.sqlRecordColumns() {
+		names = append(names, column.Name)
+	}
+
+	return names
+}
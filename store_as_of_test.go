@@ -0,0 +1,227 @@
+package customstore_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/gouniverse/customstore"
+)
+
+func execOrFail(t *testing.T, db *sql.DB, sqlStr string, args ...any) {
+	t.Helper()
+
+	if _, err := db.Exec(sqlStr, args...); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+}
+
+func TestRecordQueryAsOf(t *testing.T) {
+	db := InitDB("test_data_store_record_query_as_of.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_as_of",
+		AutomigrateEnabled: true,
+		HistoryEnabled:     true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// A is updated twice
+	recordA := customstore.NewRecord("customer")
+	recordA.SetPayload("v1")
+	if err := store.RecordCreate(ctx, recordA); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	for _, payload := range []string{"v2", "v3"} {
+		recordA.SetPayload(payload)
+		if err := store.RecordUpdate(ctx, recordA); err != nil {
+			t.Fatalf("RecordUpdate failed: %v", err)
+		}
+	}
+
+	// B is deleted
+	recordB := customstore.NewRecord("customer")
+	if err := store.RecordCreate(ctx, recordB); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordDelete(ctx, recordB); err != nil {
+		t.Fatalf("RecordDelete failed: %v", err)
+	}
+
+	// C is created later, then soft deleted
+	recordC := customstore.NewRecord("customer")
+	if err := store.RecordCreate(ctx, recordC); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordSoftDelete(ctx, recordC); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+
+	// Backdate the timeline, the changes above all happened this second
+	execOrFail(t, db, `UPDATE data_as_of SET created_at = '2024-01-01 00:00:00' WHERE id = ?`, recordA.ID())
+	execOrFail(t, db, `UPDATE data_as_of_history SET created_at = '2024-01-01 00:00:00' WHERE id IN (?, ?)`, recordA.ID(), recordB.ID())
+	execOrFail(t, db, `UPDATE data_as_of_history SET changed_at = '2024-02-01 00:00:00' WHERE id = ? AND revision = 1`, recordA.ID())
+	execOrFail(t, db, `UPDATE data_as_of_history SET changed_at = '2024-03-01 00:00:00' WHERE id = ? AND revision = 2`, recordA.ID())
+	execOrFail(t, db, `UPDATE data_as_of_history SET changed_at = '2024-02-15 00:00:00' WHERE id = ?`, recordB.ID())
+	execOrFail(t, db, `UPDATE data_as_of SET created_at = '2024-02-10 00:00:00', soft_deleted_at = '2024-03-10 00:00:00' WHERE id = ?`, recordC.ID())
+	execOrFail(t, db, `UPDATE data_as_of_history SET created_at = '2024-02-10 00:00:00', changed_at = '2024-03-10 00:00:00' WHERE id = ?`, recordC.ID())
+
+	testCases := []struct {
+		asOf        any
+		expectedIDs map[string]string // id => payload
+	}{
+		{"2023-12-31 00:00:00", map[string]string{}},
+		{"2024-01-15 00:00:00", map[string]string{recordA.ID(): "v1", recordB.ID(): ""}},
+		{time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC), map[string]string{recordA.ID(): "v2", recordC.ID(): ""}},
+		{"2024-03-05 00:00:00", map[string]string{recordA.ID(): "v3", recordC.ID(): ""}},
+		{"2024-03-15 00:00:00", map[string]string{recordA.ID(): "v3"}},
+	}
+
+	for _, tc := range testCases {
+		list, err := store.RecordList(ctx, customstore.RecordQuery().SetAsOf(tc.asOf))
+		if err != nil {
+			t.Fatalf("RecordList as of %v failed: %v", tc.asOf, err)
+		}
+
+		if len(list) != len(tc.expectedIDs) {
+			t.Fatalf("Expected %d records as of %v, got %d", len(tc.expectedIDs), tc.asOf, len(list))
+		}
+
+		for _, record := range list {
+			payload, isExpected := tc.expectedIDs[record.ID()]
+			if !isExpected {
+				t.Fatalf("Unexpected record %s as of %v", record.ID(), tc.asOf)
+			}
+			if payload != record.Payload() {
+				t.Fatalf("Expected payload %q as of %v, got %q", payload, tc.asOf, record.Payload())
+			}
+		}
+	}
+
+	found, err := store.RecordFindByIDAsOf(ctx, recordB.ID(), "2024-01-15 00:00:00")
+	if err != nil {
+		t.Fatalf("RecordFindByIDAsOf failed: %v", err)
+	}
+	if found == nil {
+		t.Fatalf("Expected the deleted record to be found as of before its deletion")
+	}
+
+	found, err = store.RecordFindByIDAsOf(ctx, recordB.ID(), "2024-03-01 00:00:00")
+	if err != nil {
+		t.Fatalf("RecordFindByIDAsOf failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected the deleted record not to be found as of after its deletion")
+	}
+
+	count, err := store.RecordCount(ctx, customstore.RecordQuery().
+		SetAsOf("2024-03-15 00:00:00").
+		SetOnlySoftDeleted(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 soft deleted record as of 2024-03-15, got %d", count)
+	}
+}
+
+func TestRecordQueryAsOfRecreated(t *testing.T) {
+	db := InitDB("test_data_store_record_query_as_of_recreated.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_as_of_recreated",
+		AutomigrateEnabled: true,
+		HistoryEnabled:     true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	record := customstore.NewRecord("customer")
+	record.SetPayload("v1")
+	if err := store.RecordCreate(ctx, record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if err := store.RecordDeleteByID(ctx, record.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+
+	if err := store.RecordRevert(ctx, record.ID(), 1); err != nil {
+		t.Fatalf("RecordRevert failed: %v", err)
+	}
+
+	// Backdate the timeline: created in January, deleted in February,
+	// recreated in March
+	execOrFail(t, db, `UPDATE data_as_of_recreated SET created_at = '2024-01-01 00:00:00' WHERE id = ?`, record.ID())
+	execOrFail(t, db, `UPDATE data_as_of_recreated_history SET created_at = '2024-01-01 00:00:00' WHERE id = ?`, record.ID())
+	execOrFail(t, db, `UPDATE data_as_of_recreated_history SET changed_at = '2024-02-01 00:00:00' WHERE id = ? AND revision = 1`, record.ID())
+	execOrFail(t, db, `UPDATE data_as_of_recreated_history SET changed_at = '2024-03-01 00:00:00' WHERE id = ? AND revision = 2`, record.ID())
+
+	testCases := []struct {
+		asOf   string
+		exists bool
+	}{
+		{"2023-12-31 00:00:00", false},
+		{"2024-01-15 00:00:00", true},
+		{"2024-02-15 00:00:00", false},
+		{"2024-03-15 00:00:00", true},
+	}
+
+	for _, tc := range testCases {
+		found, err := store.RecordFindByIDAsOf(ctx, record.ID(), tc.asOf)
+		if err != nil {
+			t.Fatalf("RecordFindByIDAsOf failed: %v", err)
+		}
+
+		if (found != nil) != tc.exists {
+			t.Fatalf("Expected the record to exist as of %s: %v, got %v", tc.asOf, tc.exists, found != nil)
+		}
+	}
+
+	revisions, err := store.RecordHistory(ctx, record.ID())
+	if err != nil {
+		t.Fatalf("RecordHistory failed: %v", err)
+	}
+
+	if len(revisions) != 2 || revisions[1].Operation != customstore.HISTORY_OPERATION_CREATE || revisions[1].Record != nil {
+		t.Fatalf("Expected the recreation to be kept as revision 2, without a state, got %+v", revisions)
+	}
+
+	if err := store.RecordRevert(ctx, record.ID(), 2); err == nil {
+		t.Fatal("Expected RecordRevert to the recreation to fail")
+	}
+}
+
+func TestRecordQueryAsOfWithHistoryDisabled(t *testing.T) {
+	db := InitDB("test_data_store_record_query_as_of_disabled.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_as_of_disabled",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	_, err = store.RecordList(context.Background(), customstore.RecordQuery().SetAsOf("2024-01-01"))
+	if err == nil {
+		t.Fatalf("Expected error with history disabled, but got nil")
+	}
+}
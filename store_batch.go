@@ -0,0 +1,509 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/base/database"
+	"github.com/gouniverse/sb"
+	"github.com/samber/lo"
+)
+
+// BatchError is returned by the batch methods when one or more records
+// could not be processed. The errors are keyed by the index of the record
+// (or ID) in the slice passed to the batch method.
+type BatchError struct {
+	Errors map[int]error
+}
+
+// Error implements the error interface
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for index := range e.Errors {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	messages := []string{}
+	for _, index := range indexes {
+		messages = append(messages, "#"+strconv.Itoa(index)+": "+e.Errors[index].Error())
+	}
+
+	return "batch failed for " + strconv.Itoa(len(e.Errors)) + " item(s): " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the per-item errors, so errors.Is and errors.As
+// can be used on the batch error
+func (e *BatchError) Unwrap() []error {
+	list := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		list = append(list, err)
+	}
+	return list
+}
+
+// RecordCreateMany creates the records using multi-row inserts, chunked to
+// stay under the parameter limit of the database. All the records are
+// created in a single transaction. On failure nothing is created, and
+// a *BatchError is returned with the errors of the failed records.
+func (st *storeImplementation) RecordCreateMany(ctx context.Context, records []RecordInterface) error {
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if len(records) < 1 {
+		return nil
+	}
+
+	batchErr := &BatchError{Errors: map[int]error{}}
+
+	for index, record := range records {
+		if record == nil {
+			batchErr.Errors[index] = errors.New("record is nil")
+			continue
+		}
+
+		if record.ID() == "" {
+			batchErr.Errors[index] = errors.New("record ID is required")
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	for index, record := range records {
+		record.SetCreatedAt(now)
+		record.SetUpdatedAt(now)
+
+		if st.versioningEnabled && record.Version() < 1 {
+			record.SetVersion(1)
+		}
+
+		if st.payloadVersioningEnabled && record.PayloadVersion() < 1 {
+			record.SetPayloadVersion(LatestPayloadVersion(record.Type()))
+		}
+
+		if err := st.hookRun(ctx, hookBeforeCreate, record, record.DataChanged()); err != nil {
+			batchErr.Errors[index] = err
+			return batchErr
+		}
+
+		if err := st.ValidatePayload(record.Type(), record.Payload()); err != nil {
+			batchErr.Errors[index] = err
+			continue
+		}
+
+		if err := st.recordColumnsCheck(record.Data()); err != nil {
+			batchErr.Errors[index] = err
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+
+	// multi-row inserts require identical columns, so the records
+	// are grouped by their column sets, preserving the order
+	groups := [][]int{}
+	groupIndexBySignature := map[string]int{}
+
+	for index, record := range records {
+		signature := columnsSignature(record.Data())
+
+		groupIndex, exists := groupIndexBySignature[signature]
+		if !exists {
+			groupIndex = len(groups)
+			groupIndexBySignature[signature] = groupIndex
+			groups = append(groups, []int{})
+		}
+
+		groups[groupIndex] = append(groups[groupIndex], index)
+	}
+
+	changes := make([]map[string]string, len(records))
+	changeEntries := make([]changeEntry, len(records))
+
+	for index, record := range records {
+		changes[index] = maps.Clone(record.DataChanged())
+		changeEntries[index] = changeEntry{
+			recordID:   record.ID(),
+			recordType: record.Type(),
+			changed:    changes[index],
+		}
+	}
+
+	err := st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		for _, group := range groups {
+			columnCount := len(records[group[0]].Data())
+
+			for _, chunk := range lo.Chunk(group, max(maxParamsPerStatement(st.dbDriverName)/columnCount, 1)) {
+				err := txStore.savepointRun(ctx, func() error {
+					return txStore.recordInsertRows(ctx, records, chunk)
+				})
+
+				if err == nil {
+					continue
+				}
+
+				// the records of the chunk are retried one by one,
+				// to find which of them failed
+				for _, index := range chunk {
+					errRecord := txStore.savepointRun(ctx, func() error {
+						return txStore.recordInsertRows(ctx, records, []int{index})
+					})
+
+					if errRecord != nil {
+						batchErr.Errors[index] = errRecord
+					}
+				}
+			}
+		}
+
+		if len(batchErr.Errors) > 0 {
+			return batchErr
+		}
+
+		return txStore.changeLog(ctx, CHANGE_OPERATION_CREATE, changeEntries...)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		record.MarkAsNotDirty()
+	}
+
+	for index, record := range records {
+		if err := st.hookRun(ctx, hookAfterCreate, record, changes[index]); err != nil {
+			batchErr.Errors[index] = err
+			return batchErr
+		}
+	}
+
+	return nil
+}
+
+// recordInsertRows inserts the records at the indexes with a multi-row insert
+func (st *storeImplementation) recordInsertRows(ctx context.Context, records []RecordInterface, indexes []int) error {
+	rows := []any{}
+	for _, index := range indexes {
+		rows = append(rows, records[index].Data())
+	}
+
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		Insert(st.tableName).
+		Prepared(true).
+		Rows(rows...).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Record create many query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	_, err = database.Execute(qctx, sqlStr, sqlParams...)
+
+	return err
+}
+
+// RecordUpdateMany updates the records in a single transaction. On failure
+// nothing is updated, the records are left as they were passed, still dirty
+// and at their version, and a *BatchError is returned with the errors
+// of the failed records.
+func (st *storeImplementation) RecordUpdateMany(ctx context.Context, records []RecordInterface) error {
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if len(records) < 1 {
+		return nil
+	}
+
+	batchErr := &BatchError{Errors: map[int]error{}}
+
+	for index, record := range records {
+		if record == nil {
+			batchErr.Errors[index] = errors.New("record is nil")
+			continue
+		}
+
+		if record.ID() == "" {
+			batchErr.Errors[index] = errors.New("record id is required")
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+
+	// each update marks its record as not dirty, and bumps its version,
+	// before the transaction commits, so the records are restored on failure
+	snapshots := make([]recordSnapshot, 0, len(records))
+	for _, record := range records {
+		snapshots = append(snapshots, newRecordSnapshot(record))
+	}
+
+	err := st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		for index, record := range records {
+			if err := txStore.RecordUpdate(ctx, record); err != nil {
+				batchErr.Errors[index] = err
+				return batchErr // the transaction is aborted, no point in continuing
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		for index, record := range records {
+			snapshots[index].restore(record)
+		}
+	}
+
+	return err
+}
+
+// recordSnapshot is the in-memory state of a record, its data and the data
+// changed since it was loaded
+type recordSnapshot struct {
+	data    map[string]string
+	changed map[string]string
+}
+
+func newRecordSnapshot(record RecordInterface) recordSnapshot {
+	return recordSnapshot{
+		data:    maps.Clone(record.Data()),
+		changed: maps.Clone(record.DataChanged()),
+	}
+}
+
+// restore puts the record back in the state of the snapshot
+func (s recordSnapshot) restore(record RecordInterface) {
+	record.MarkAsNotDirty()
+	record.Hydrate(maps.Clone(s.data))
+	record.SetData(s.changed)
+}
+
+// RecordDeleteByIDs permanently deletes the records with the given IDs,
+// using IN clauses chunked to stay under the parameter limit of the
+// database. All the records are deleted in a single transaction.
+func (st *storeImplementation) RecordDeleteByIDs(ctx context.Context, ids []string) error {
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if len(ids) < 1 {
+		return nil
+	}
+
+	batchErr := &BatchError{Errors: map[int]error{}}
+
+	for index, id := range ids {
+		if id == "" {
+			batchErr.Errors[index] = errors.New("record id is empty")
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+
+	if st.hookExists(hookBeforeDelete) || st.hookExists(hookAfterDelete) {
+		// the records are deleted one by one, so the hooks run for each
+		return st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+			for index, id := range ids {
+				if err := txStore.RecordDeleteByID(ctx, id); err != nil {
+					batchErr.Errors[index] = err
+					return batchErr
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		for _, chunk := range lo.Chunk(ids, maxParamsPerStatement(st.dbDriverName)) {
+			sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+				Delete(st.tableName).
+				Prepared(true).
+				Where(goqu.C(COLUMN_ID).In(chunk)).
+				ToSQL()
+
+			if err != nil {
+				return err
+			}
+
+			if st.debugEnabled {
+				st.logger.Debug("Record delete many query", "query", sqlStr, "params", sqlParams)
+			}
+
+			if err := txStore.historySnapshot(ctx, HISTORY_OPERATION_DELETE, goqu.C(COLUMN_ID).In(chunk)); err != nil {
+				return err
+			}
+
+			changes, err := txStore.changeEntriesWhere(ctx, nil, goqu.C(COLUMN_ID).In(chunk))
+			if err != nil {
+				return err
+			}
+
+			qctx, cancel := txStore.toQuerableContext(ctx)
+			_, err = database.Execute(qctx, sqlStr, sqlParams...)
+			cancel()
+
+			if err != nil {
+				return err
+			}
+
+			if err := txStore.changeLog(ctx, CHANGE_OPERATION_DELETE, changes...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RecordSoftDeleteByIDs soft deletes the records with the given IDs, which
+// are not soft deleted yet, using chunked IN clauses in a single transaction
+func (st *storeImplementation) RecordSoftDeleteByIDs(ctx context.Context, ids []string) error {
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if len(ids) < 1 {
+		return nil
+	}
+
+	batchErr := &BatchError{Errors: map[int]error{}}
+
+	for index, id := range ids {
+		if id == "" {
+			batchErr.Errors[index] = errors.New("record id is empty")
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+
+	if st.hookExists(hookBeforeUpdate) || st.hookExists(hookAfterUpdate) {
+		// the records are soft deleted one by one, so the hooks run for each
+		return st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+			for index, id := range ids {
+				existing, exists, err := txStore.recordExisting(ctx, id)
+
+				if err == nil && exists && carbon.Parse(existing[COLUMN_SOFT_DELETED_AT], carbon.UTC).Gt(carbon.Now(carbon.UTC)) {
+					err = txStore.RecordSoftDelete(ctx, txStore.recordFromExistingData(existing))
+				}
+
+				if err != nil {
+					batchErr.Errors[index] = err
+					return batchErr
+				}
+			}
+
+			return nil
+		})
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	fields := map[string]any{
+		COLUMN_SOFT_DELETED_AT: now,
+		COLUMN_UPDATED_AT:      now,
+	}
+
+	if st.versioningEnabled {
+		fields[COLUMN_VERSION] = goqu.L("? + 1", goqu.I(COLUMN_VERSION))
+	}
+
+	return st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		for _, chunk := range lo.Chunk(ids, maxParamsPerStatement(st.dbDriverName)-2) {
+			where := []goqu.Expression{
+				goqu.C(COLUMN_ID).In(chunk),
+				goqu.C(COLUMN_SOFT_DELETED_AT).Gt(now),
+			}
+
+			sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+				Update(st.tableName).
+				Prepared(true).
+				Set(fields).
+				Where(where...).
+				ToSQL()
+
+			if err != nil {
+				return err
+			}
+
+			if st.debugEnabled {
+				st.logger.Debug("Record soft delete many query", "query", sqlStr, "params", sqlParams)
+			}
+
+			if err := txStore.historySnapshot(ctx, HISTORY_OPERATION_SOFT_DELETE, where...); err != nil {
+				return err
+			}
+
+			changes, err := txStore.changeEntriesWhere(ctx, map[string]string{
+				COLUMN_SOFT_DELETED_AT: now,
+				COLUMN_UPDATED_AT:      now,
+			}, where...)
+			if err != nil {
+				return err
+			}
+
+			qctx, cancel := txStore.toQuerableContext(ctx)
+			_, err = database.Execute(qctx, sqlStr, sqlParams...)
+			cancel()
+
+			if err != nil {
+				return err
+			}
+
+			if err := txStore.changeLog(ctx, CHANGE_OPERATION_SOFT_DELETE, changes...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// maxParamsPerStatement returns the maximum number of bound parameters
+// a single statement may use for the database driver
+func maxParamsPerStatement(driver string) int {
+	switch driver {
+	case sb.DIALECT_MYSQL, sb.DIALECT_POSTGRES:
+		return 65535
+	case sb.DIALECT_MSSQL:
+		return 2100
+	default:
+		return 999 // SQLite before 3.32, the lowest common denominator
+	}
+}
+
+// columnsSignature returns a key identifying the set of columns in the data
+func columnsSignature(data map[string]string) string {
+	columns := make([]string, 0, len(data))
+	for column := range data {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return strings.Join(columns, ",")
+}
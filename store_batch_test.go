@@ -0,0 +1,271 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordCreateMany(t *testing.T) {
+	db := InitDB("test_data_store_record_create_many.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_create_many",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// More records than fit in a single SQLite statement
+	records := []customstore.RecordInterface{}
+	for i := 0; i < 500; i++ {
+		record := customstore.NewRecord("person")
+		if err := record.SetPayloadMap(map[string]any{"index": i}); err != nil {
+			t.Fatalf("SetPayloadMap failed: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	err = store.RecordCreateMany(context.Background(), records)
+	if err != nil {
+		t.Fatalf("RecordCreateMany failed: %v", err)
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+
+	if count != 500 {
+		t.Fatalf("Expected count 500, but got %d", count)
+	}
+}
+
+func TestRecordCreateManyRollsBackOnError(t *testing.T) {
+	db := InitDB("test_data_store_record_create_many_rollback.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_create_many_rollback",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// Invalid records are reported without touching the database
+	invalid := customstore.NewRecord("person")
+	invalid.SetID("")
+
+	err = store.RecordCreateMany(context.Background(), []customstore.RecordInterface{
+		customstore.NewRecord("person"),
+		invalid,
+	})
+
+	var batchErr *customstore.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected *BatchError, but got %v", err)
+	}
+
+	if len(batchErr.Errors) != 1 || batchErr.Errors[1] == nil {
+		t.Fatalf("Expected a single error for record #1, but got %v", batchErr.Errors)
+	}
+
+	// A duplicate ID fails the chunk, and nothing is created
+	existing := customstore.NewRecord("person")
+	if err := store.RecordCreate(context.Background(), existing); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	duplicate := customstore.NewRecord("person")
+	duplicate.SetID(existing.ID())
+
+	repeated := customstore.NewRecord("person")
+	repeatedAgain := customstore.NewRecord("person")
+	repeatedAgain.SetID(repeated.ID())
+
+	err = store.RecordCreateMany(context.Background(), []customstore.RecordInterface{
+		customstore.NewRecord("person"),
+		duplicate,
+		repeated,
+		repeatedAgain,
+	})
+
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected *BatchError, but got %v", err)
+	}
+
+	// only the records causing the failure are reported
+	if len(batchErr.Errors) != 2 || batchErr.Errors[1] == nil || batchErr.Errors[3] == nil {
+		t.Fatalf("Expected errors for records #1 and #3 only, but got %v", batchErr.Errors)
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("Expected count 1 after rollback, but got %d", count)
+	}
+}
+
+func TestRecordUpdateMany(t *testing.T) {
+	db := InitDB("test_data_store_record_update_many.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_update_many",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{}
+	for i := 0; i < 3; i++ {
+		records = append(records, customstore.NewRecord("person"))
+	}
+
+	if err := store.RecordCreateMany(context.Background(), records); err != nil {
+		t.Fatalf("RecordCreateMany failed: %v", err)
+	}
+
+	for i, record := range records {
+		record.SetMemo("memo " + strconv.Itoa(i))
+	}
+
+	if err := store.RecordUpdateMany(context.Background(), records); err != nil {
+		t.Fatalf("RecordUpdateMany failed: %v", err)
+	}
+
+	for i, record := range records {
+		found, err := store.RecordFindByID(context.Background(), record.ID())
+		if err != nil {
+			t.Fatalf("RecordFindByID failed: %v", err)
+		}
+		if found == nil {
+			t.Fatalf("Record %d not found", i)
+		}
+		if found.Memo() != "memo "+strconv.Itoa(i) {
+			t.Fatalf("Expected memo %q, but got %q", "memo "+strconv.Itoa(i), found.Memo())
+		}
+	}
+}
+
+func TestRecordUpdateManyRollsBackOnError(t *testing.T) {
+	db := InitDB("test_data_store_record_update_many_rollback.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_update_many_rollback",
+		AutomigrateEnabled: true,
+		VersioningEnabled:  true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	a := customstore.NewRecord("person")
+	b := customstore.NewRecord("person")
+	if err := store.RecordCreateMany(ctx, []customstore.RecordInterface{a, b}); err != nil {
+		t.Fatalf("RecordCreateMany failed: %v", err)
+	}
+
+	// b is stale, another writer updated it since
+	concurrent, err := store.RecordFindByID(ctx, b.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	concurrent.SetMemo("concurrent")
+	if err := store.RecordUpdate(ctx, concurrent); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	a.SetMemo("memo a")
+	b.SetMemo("memo b")
+
+	err = store.RecordUpdateMany(ctx, []customstore.RecordInterface{a, b})
+
+	var batchErr *customstore.BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors[1], customstore.ErrVersionConflict) {
+		t.Fatalf("Expected a *BatchError with the version conflict of the second record, got %v", err)
+	}
+
+	// the update of a is rolled back, so a is left dirty at its version
+	if a.Version() != 1 || a.DataChanged()[customstore.COLUMN_MEMO] != "memo a" {
+		t.Fatalf("Expected a to be left dirty at version 1, got version %d and changes %v", a.Version(), a.DataChanged())
+	}
+
+	if err := store.RecordUpdate(ctx, a); err != nil {
+		t.Fatalf("Expected the retry of a to succeed, got %v", err)
+	}
+
+	found, err := store.RecordFindByID(ctx, a.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	if found.Memo() != "memo a" || found.Version() != 2 {
+		t.Fatalf("Expected memo a at version 2, got %q at version %d", found.Memo(), found.Version())
+	}
+}
+
+func TestRecordDeleteByIDs(t *testing.T) {
+	db := InitDB("test_data_store_record_delete_by_ids.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_delete_by_ids",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{}
+	for i := 0; i < 5; i++ {
+		records = append(records, customstore.NewRecord("person"))
+	}
+
+	if err := store.RecordCreateMany(context.Background(), records); err != nil {
+		t.Fatalf("RecordCreateMany failed: %v", err)
+	}
+
+	err = store.RecordDeleteByIDs(context.Background(), []string{records[0].ID(), ""})
+	if err == nil {
+		t.Fatalf("Expected error when deleting an empty ID, but got nil")
+	}
+
+	err = store.RecordDeleteByIDs(context.Background(), []string{records[0].ID(), records[2].ID(), records[4].ID()})
+	if err != nil {
+		t.Fatalf("RecordDeleteByIDs failed: %v", err)
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("Expected count 2, but got %d", count)
+	}
+}
@@ -0,0 +1,498 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/base/database"
+	"github.com/samber/lo"
+	"github.com/spf13/cast"
+)
+
+// defaultChangesLimit is the number of changes returned by ChangesSince,
+// when the limit is not set
+const defaultChangesLimit = 100
+
+// Change is an entry of the change feed, written in the same transaction
+// as the change of the record
+type Change struct {
+	// Sequence increases monotonically with each change
+	Sequence int64
+
+	// Operation is the type of change, i.e. CHANGE_OPERATION_CREATE
+	Operation string
+
+	// RecordID is the ID of the changed record
+	RecordID string
+
+	// RecordType is the type of the changed record
+	RecordType string
+
+	// Changed are the changed fields, empty for deletes
+	Changed map[string]string
+
+	// ChangedAt is when the change was made
+	ChangedAt string
+}
+
+// changeEntry is a change to write to the change feed
+type changeEntry struct {
+	recordID   string
+	recordType string
+	changed    map[string]string
+}
+
+// ChangesSince returns the changes after the sequence, oldest first, up to
+// the limit (100 if not set). Polling from the last sequence processed, kept
+// with ChangeCheckpointSet, streams the changes with at-least-once delivery
+func (st *storeImplementation) ChangesSince(ctx context.Context, sequence int64, limit int) ([]Change, error) {
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if !st.changesEnabled {
+		return nil, errors.New("changes are not enabled")
+	}
+
+	if limit < 1 {
+		limit = defaultChangesLimit
+	}
+
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		From(st.changesTableName()).
+		Prepared(true).
+		Where(goqu.C(COLUMN_SEQUENCE).Gt(sequence)).
+		Order(goqu.C(COLUMN_SEQUENCE).Asc()).
+		Limit(uint(limit)).
+		ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Changes since query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	rows, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0, len(rows))
+	for _, row := range rows {
+		changed := map[string]string{}
+		if row[COLUMN_CHANGED] != "" {
+			if err := json.Unmarshal([]byte(row[COLUMN_CHANGED]), &changed); err != nil {
+				return nil, err
+			}
+		}
+
+		changes = append(changes, Change{
+			Sequence:   cast.ToInt64(row[COLUMN_SEQUENCE]),
+			Operation:  row[COLUMN_OPERATION],
+			RecordID:   row[COLUMN_RECORD_ID],
+			RecordType: row[COLUMN_RECORD_TYPE],
+			Changed:    changed,
+			ChangedAt:  toDateTimeValue(row[COLUMN_CHANGED_AT]),
+		})
+	}
+
+	return changes, nil
+}
+
+// ChangeCheckpointGet returns the last sequence the consumer processed,
+// 0 if it has not set a checkpoint yet
+func (st *storeImplementation) ChangeCheckpointGet(ctx context.Context, consumer string) (int64, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if !st.changesEnabled {
+		return 0, errors.New("changes are not enabled")
+	}
+
+	if consumer == "" {
+		return 0, errors.New("consumer is empty")
+	}
+
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		From(st.checkpointsTableName()).
+		Prepared(true).
+		Select(goqu.C(COLUMN_SEQUENCE)).
+		Where(goqu.C(COLUMN_CONSUMER).Eq(consumer)).
+		Limit(1).
+		ToSQL()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Change checkpoint query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	rows, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(rows) < 1 {
+		return 0, nil
+	}
+
+	return cast.ToInt64(rows[0][COLUMN_SEQUENCE]), nil
+}
+
+// ChangeCheckpointSet keeps the last sequence the consumer processed
+func (st *storeImplementation) ChangeCheckpointSet(ctx context.Context, consumer string, sequence int64) error {
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if !st.changesEnabled {
+		return errors.New("changes are not enabled")
+	}
+
+	if consumer == "" {
+		return errors.New("consumer is empty")
+	}
+
+	return st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+			From(st.checkpointsTableName()).
+			Prepared(true).
+			Select(goqu.C(COLUMN_CONSUMER)).
+			Where(goqu.C(COLUMN_CONSUMER).Eq(consumer)).
+			Limit(1).
+			ToSQL()
+
+		if err != nil {
+			return err
+		}
+
+		qctx, cancel := txStore.toQuerableContext(ctx)
+		defer cancel()
+
+		rows, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+		if err != nil {
+			return err
+		}
+
+		fields := goqu.Record{
+			COLUMN_CONSUMER:   consumer,
+			COLUMN_SEQUENCE:   sequence,
+			COLUMN_UPDATED_AT: carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC),
+		}
+
+		if len(rows) > 0 {
+			sqlStr, sqlParams, err = goqu.Dialect(st.dbDriverName).
+				Update(st.checkpointsTableName()).
+				Prepared(true).
+				Set(fields).
+				Where(goqu.C(COLUMN_CONSUMER).Eq(consumer)).
+				ToSQL()
+		} else {
+			sqlStr, sqlParams, err = goqu.Dialect(st.dbDriverName).
+				Insert(st.checkpointsTableName()).
+				Prepared(true).
+				Rows(fields).
+				ToSQL()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if st.debugEnabled {
+			st.logger.Debug("Change checkpoint set query", "query", sqlStr, "params", sqlParams)
+		}
+
+		_, err = database.Execute(qctx, sqlStr, sqlParams...)
+
+		return err
+	})
+}
+
+// changesTableName returns the name of the change feed table
+func (st *storeImplementation) changesTableName() string {
+	return st.tableName + "_changes"
+}
+
+// changesSequenceTableName returns the name of the table holding the
+// counter of the change sequence, in a single row
+func (st *storeImplementation) changesSequenceTableName() string {
+	return st.tableName + "_changes_sequence"
+}
+
+// checkpointsTableName returns the name of the consumer checkpoints table
+func (st *storeImplementation) checkpointsTableName() string {
+	return st.tableName + "_checkpoints"
+}
+
+// changeLog writes the changes to the change feed, and sends them to the
+// subscribers. It must run in the transaction of the write, after the write.
+func (st *storeImplementation) changeLog(ctx context.Context, operation string, entries ...changeEntry) error {
+	if len(entries) < 1 {
+		return nil
+	}
+
+	if st.subscribed() {
+		events := make([]ChangeEvent, 0, len(entries))
+		for _, entry := range entries {
+			events = append(events, ChangeEvent{
+				Operation:  operation,
+				RecordID:   entry.recordID,
+				RecordType: entry.recordType,
+				Changed:    maps.Clone(entry.changed),
+			})
+		}
+
+		st.changePublish(events)
+	}
+
+	if !st.changesEnabled {
+		return nil
+	}
+
+	lastSequence, err := st.changeSequenceAllocate(ctx, int64(len(entries)))
+
+	if err != nil {
+		return err
+	}
+
+	sequence := lastSequence - int64(len(entries))
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	changeRows := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		changed := entry.changed
+		if changed == nil {
+			changed = map[string]string{}
+		}
+
+		changedJSON, err := json.Marshal(changed)
+		if err != nil {
+			return err
+		}
+
+		sequence++
+
+		changeRows = append(changeRows, goqu.Record{
+			COLUMN_SEQUENCE:    sequence,
+			COLUMN_OPERATION:   operation,
+			COLUMN_RECORD_ID:   entry.recordID,
+			COLUMN_RECORD_TYPE: entry.recordType,
+			COLUMN_CHANGED:     string(changedJSON),
+			COLUMN_CHANGED_AT:  now,
+		})
+	}
+
+	for _, chunk := range lo.Chunk(changeRows, max(maxParamsPerStatement(st.dbDriverName)/6, 1)) {
+		sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+			Insert(st.changesTableName()).
+			Prepared(true).
+			Rows(chunk...).
+			ToSQL()
+
+		if err != nil {
+			return err
+		}
+
+		if st.debugEnabled {
+			st.logger.Debug("Change log query", "query", sqlStr, "params", sqlParams)
+		}
+
+		if _, err := database.Execute(qctx, sqlStr, sqlParams...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// changeSequenceAllocate allocates the next count sequences, and returns the
+// last one. It must run in the transaction of the write.
+//
+// The counter row is updated first, so it stays locked until the commit:
+// concurrent writers wait for each other, rather than allocating the same
+// sequence, and commit their changes in the order of the sequence
+func (st *storeImplementation) changeSequenceAllocate(ctx context.Context, count int64) (int64, error) {
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		Update(st.changesSequenceTableName()).
+		Prepared(true).
+		Set(goqu.Record{COLUMN_SEQUENCE: goqu.L("? + ?", goqu.I(COLUMN_SEQUENCE), count)}).
+		ToSQL()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Change sequence allocate query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	result, err := database.Execute(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if rowsAffected < 1 {
+		return 0, errors.New("change sequence is not initialized, AutoMigrate must be run")
+	}
+
+	sqlStr, sqlParams, err = goqu.Dialect(st.dbDriverName).
+		From(st.changesSequenceTableName()).
+		Prepared(true).
+		Select(goqu.C(COLUMN_SEQUENCE)).
+		ToSQL()
+
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(rows) < 1 {
+		return 0, errors.New("change sequence is not initialized, AutoMigrate must be run")
+	}
+
+	return cast.ToInt64(rows[0][COLUMN_SEQUENCE]), nil
+}
+
+// changeSequenceInit inserts the counter row of the change sequence if it
+// does not exist, continuing from the last change logged
+func (st *storeImplementation) changeSequenceInit(ctx context.Context) error {
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		From(st.changesSequenceTableName()).
+		Prepared(true).
+		Select(goqu.C(COLUMN_SEQUENCE)).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return err
+	}
+
+	if len(rows) > 0 {
+		return nil
+	}
+
+	sqlStr, sqlParams, err = goqu.Dialect(st.dbDriverName).
+		From(st.changesTableName()).
+		Prepared(true).
+		Select(goqu.COALESCE(goqu.MAX(COLUMN_SEQUENCE), 0).As(COLUMN_SEQUENCE)).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	rows, err = database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return err
+	}
+
+	sequence := int64(0)
+	if len(rows) > 0 {
+		sequence = cast.ToInt64(rows[0][COLUMN_SEQUENCE])
+	}
+
+	sqlStr, sqlParams, err = goqu.Dialect(st.dbDriverName).
+		Insert(st.changesSequenceTableName()).
+		Prepared(true).
+		Rows(goqu.Record{COLUMN_SEQUENCE: sequence}).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Change sequence init query", "query", sqlStr, "params", sqlParams)
+	}
+
+	_, err = database.Execute(qctx, sqlStr, sqlParams...)
+
+	return err
+}
+
+// changeEntriesWhere returns the change entries of the records matching the
+// conditions, all with the same changed fields. It must run before the write
+func (st *storeImplementation) changeEntriesWhere(ctx context.Context, changed map[string]string, where ...exp.Expression) ([]changeEntry, error) {
+	if !st.changesEnabled && !st.subscribed() {
+		return nil, nil
+	}
+
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		From(st.tableName).
+		Prepared(true).
+		Select(goqu.C(COLUMN_ID), goqu.C(COLUMN_RECORD_TYPE)).
+		Where(where...).
+		ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	rows, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]changeEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, changeEntry{
+			recordID:   row[COLUMN_ID],
+			recordType: row[COLUMN_RECORD_TYPE],
+			changed:    changed,
+		})
+	}
+
+	return entries, nil
+}
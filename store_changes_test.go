@@ -0,0 +1,246 @@
+package customstore_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestStoreChanges(t *testing.T) {
+	db := InitDB("test_data_store_changes.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_changes",
+		AutomigrateEnabled: true,
+		ChangesEnabled:     true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	person := customstore.NewRecord("person")
+	person.SetPayload(`{"name":"Ann"}`)
+	if err := store.RecordCreate(ctx, person); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	person.SetMemo("updated")
+	if err := store.RecordUpdate(ctx, person); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	first := customstore.NewRecord("task")
+	second := customstore.NewRecord("task")
+	if err := store.RecordCreateMany(ctx, []customstore.RecordInterface{first, second}); err != nil {
+		t.Fatalf("RecordCreateMany failed: %v", err)
+	}
+
+	if err := store.RecordSoftDeleteByIDs(ctx, []string{first.ID()}); err != nil {
+		t.Fatalf("RecordSoftDeleteByIDs failed: %v", err)
+	}
+
+	if err := store.RecordDeleteByID(ctx, second.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+
+	changes, err := store.ChangesSince(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("ChangesSince failed: %v", err)
+	}
+
+	expected := []struct {
+		operation string
+		recordID  string
+	}{
+		{customstore.CHANGE_OPERATION_CREATE, person.ID()},
+		{customstore.CHANGE_OPERATION_UPDATE, person.ID()},
+		{customstore.CHANGE_OPERATION_CREATE, first.ID()},
+		{customstore.CHANGE_OPERATION_CREATE, second.ID()},
+		{customstore.CHANGE_OPERATION_SOFT_DELETE, first.ID()},
+		{customstore.CHANGE_OPERATION_DELETE, second.ID()},
+	}
+
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+
+	for index, change := range changes {
+		if change.Sequence != int64(index+1) {
+			t.Fatalf("Expected change #%d to have sequence %d, got %d", index, index+1, change.Sequence)
+		}
+
+		if change.Operation != expected[index].operation || change.RecordID != expected[index].recordID {
+			t.Fatalf("Expected change #%d to be %s of %s, got %s of %s", index, expected[index].operation, expected[index].recordID, change.Operation, change.RecordID)
+		}
+	}
+
+	if changes[0].RecordType != "person" || changes[0].Changed[customstore.COLUMN_PAYLOAD] != `{"name":"Ann"}` {
+		t.Fatalf("Expected the create to hold the type and payload, got %+v", changes[0])
+	}
+
+	if changes[1].Changed[customstore.COLUMN_MEMO] != "updated" {
+		t.Fatalf("Expected the update to hold the changed memo, got %+v", changes[1].Changed)
+	}
+
+	if _, isChanged := changes[1].Changed[customstore.COLUMN_PAYLOAD]; isChanged {
+		t.Fatalf("Expected the update to hold only the changed fields, got %+v", changes[1].Changed)
+	}
+
+	if changes[5].RecordType != "task" || len(changes[5].Changed) != 0 {
+		t.Fatalf("Expected the delete to hold the type and no fields, got %+v", changes[5])
+	}
+
+	// a failed write does not leave a change behind
+	if err := store.RecordCreate(ctx, person); err == nil {
+		t.Fatal("Expected creating a duplicate record to fail")
+	}
+
+	// the poller reads in pages, keeping a checkpoint
+	sequence, err := store.ChangeCheckpointGet(ctx, "indexer")
+	if err != nil {
+		t.Fatalf("ChangeCheckpointGet failed: %v", err)
+	}
+
+	if sequence != 0 {
+		t.Fatalf("Expected no checkpoint yet, got %d", sequence)
+	}
+
+	read := 0
+	for {
+		page, err := store.ChangesSince(ctx, sequence, 4)
+		if err != nil {
+			t.Fatalf("ChangesSince failed: %v", err)
+		}
+
+		if len(page) < 1 {
+			break
+		}
+
+		read += len(page)
+		sequence = page[len(page)-1].Sequence
+
+		if err := store.ChangeCheckpointSet(ctx, "indexer", sequence); err != nil {
+			t.Fatalf("ChangeCheckpointSet failed: %v", err)
+		}
+	}
+
+	if read != len(expected) {
+		t.Fatalf("Expected the poller to read %d changes, got %d", len(expected), read)
+	}
+
+	sequence, err = store.ChangeCheckpointGet(ctx, "indexer")
+	if err != nil {
+		t.Fatalf("ChangeCheckpointGet failed: %v", err)
+	}
+
+	if sequence != int64(len(expected)) {
+		t.Fatalf("Expected the checkpoint to be %d, got %d", len(expected), sequence)
+	}
+}
+
+func TestStoreChangesNotEnabled(t *testing.T) {
+	db := InitDB("test_data_store_changes_not_enabled.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_changes_not_enabled",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.RecordCreate(context.Background(), customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, err := store.ChangesSince(context.Background(), 0, 10); err == nil {
+		t.Fatal("Expected ChangesSince to fail when changes are not enabled")
+	}
+}
+
+func TestStoreChangesConcurrentWriters(t *testing.T) {
+	os.Remove("test_data_store_changes_concurrent.db")
+	db, err := sql.Open("sqlite3", "test_data_store_changes_concurrent.db?parseTime=true&_busy_timeout=10000")
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	defer db.Close()
+
+	options := customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_changes_concurrent",
+		AutomigrateEnabled: true,
+		ChangesEnabled:     true,
+	}
+
+	store, err := customstore.NewStore(options)
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	const writers = 2
+	const recordsPerWriter = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*recordsPerWriter)
+
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range recordsPerWriter {
+				errs <- store.RecordCreate(ctx, customstore.NewRecord("person"))
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	// the sequence continues after the store is migrated again
+	store, err = customstore.NewStore(options)
+
+	if err != nil {
+		t.Fatalf("Store could not be created again: %v", err)
+	}
+
+	if err := store.RecordCreate(ctx, customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	changes, err := store.ChangesSince(ctx, 0, 100)
+	if err != nil {
+		t.Fatalf("ChangesSince failed: %v", err)
+	}
+
+	if len(changes) != writers*recordsPerWriter+1 {
+		t.Fatalf("Expected %d changes, got %d", writers*recordsPerWriter+1, len(changes))
+	}
+
+	for index, change := range changes {
+		if change.Sequence != int64(index+1) {
+			t.Fatalf("Expected change #%d to have the sequence %d, got %d", index, index+1, change.Sequence)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/samber/lo"
+)
+
+// RecordDeleteByQuery deletes all records matching the query in a single
+// transaction and returns the number of deleted records
+func (st *storeImplementation) RecordDeleteByQuery(ctx context.Context, query RecordQueryInterface) (int64, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if query == nil {
+		return 0, errors.New("query is nil")
+	}
+
+	var deleted int64
+
+	err := st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		records, err := txStore.recordListChunked(ctx, query)
+
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record.ID())
+		}
+
+		if err := txStore.RecordDeleteByIDs(ctx, ids); err != nil {
+			return err
+		}
+
+		deleted = int64(len(ids))
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// RecordSoftDeleteByQuery soft deletes all records matching the query in a
+// single transaction and returns the number of soft deleted records
+func (st *storeImplementation) RecordSoftDeleteByQuery(ctx context.Context, query RecordQueryInterface) (int64, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if query == nil {
+		return 0, errors.New("query is nil")
+	}
+
+	var softDeleted int64
+
+	err := st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		records, err := txStore.recordListChunked(ctx, query)
+
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if record.IsSoftDeleted() {
+				continue // already soft deleted, when included by the query
+			}
+
+			if err := txStore.RecordSoftDelete(ctx, record); err != nil {
+				return err
+			}
+
+			softDeleted++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return softDeleted, nil
+}
+
+// recordListChunked lists the records matching the query, as RecordList,
+// splitting an ID IN list too long for a single statement into several
+// queries. A query with a limit or an offset is not split, as the pages
+// of the chunks would not add up to its page
+func (st *storeImplementation) recordListChunked(ctx context.Context, query RecordQueryInterface) ([]RecordInterface, error) {
+	ids := query.GetIDIn()
+
+	// half of the parameters are left to the other conditions of the query
+	chunkSize := max(maxParamsPerStatement(st.dbDriverName)/2, 1)
+
+	if !query.IsIDInSet() || len(ids) <= chunkSize || query.IsLimitSet() || query.IsOffsetSet() {
+		return st.RecordList(ctx, query)
+	}
+
+	defer query.SetIDIn(ids)
+
+	records := []RecordInterface{}
+
+	for _, chunk := range lo.Chunk(lo.Uniq(ids), chunkSize) {
+		list, err := st.RecordList(ctx, query.SetIDIn(chunk))
+
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, list...)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,70 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// expireSweepBatchSize is the number of expired records swept per batch
+const expireSweepBatchSize = 1000
+
+// ExpireSweep deletes the expired records in batches, or soft deletes them
+// when ExpirySweepSoftDelete is set, and returns the number of swept records
+func (st *storeImplementation) ExpireSweep(ctx context.Context) (int64, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if !st.expiryEnabled {
+		return 0, errors.New("expiry is not enabled")
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	var swept int64
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return swept, err
+		}
+
+		query := RecordQuery().
+			SetColumns([]string{COLUMN_ID}).
+			SetExpiredIncluded(true).
+			Where(ColumnFilter(COLUMN_EXPIRES_AT, "<=", now)).
+			SetLimit(expireSweepBatchSize)
+
+		if !st.expirySoftDelete {
+			query.SetSoftDeletedIncluded(true) // expired, soft deleted records go too
+		}
+
+		records, err := st.RecordList(ctx, query)
+
+		if err != nil {
+			return swept, err
+		}
+
+		ids := make([]string, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record.ID())
+		}
+
+		if st.expirySoftDelete {
+			err = st.RecordSoftDeleteByIDs(ctx, ids)
+		} else {
+			err = st.RecordDeleteByIDs(ctx, ids)
+		}
+
+		if err != nil {
+			return swept, err
+		}
+
+		swept += int64(len(ids))
+
+		if len(ids) < expireSweepBatchSize {
+			return swept, nil
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package customstore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordExpiry(t *testing.T) {
+	db := InitDB("test_data_store_record_expiry.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_expiry",
+		AutomigrateEnabled: true,
+		ExpiryEnabled:      true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	expired := customstore.NewRecord("token")
+	expired.SetExpiresAt(carbon.Now(carbon.UTC).SubHour().ToDateTimeString(carbon.UTC))
+
+	valid := customstore.NewRecord("token")
+	valid.SetExpiresAt(carbon.Now(carbon.UTC).AddHour().ToDateTimeString(carbon.UTC))
+
+	permanent := customstore.NewRecord("token")
+
+	for _, record := range []customstore.RecordInterface{expired, valid, permanent} {
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	if !expired.IsExpired() {
+		t.Fatalf("Expected the record to be expired")
+	}
+
+	if valid.IsExpired() || permanent.IsExpired() {
+		t.Fatalf("Expected the records not to be expired")
+	}
+
+	found, err := store.RecordFindByID(context.Background(), expired.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected the expired record not to be found")
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 records not expired, got %d", count)
+	}
+
+	count, err = store.RecordCount(context.Background(), customstore.RecordQuery().SetExpiredIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 records with expired included, got %d", count)
+	}
+
+	swept, err := store.ExpireSweep(context.Background())
+	if err != nil {
+		t.Fatalf("ExpireSweep failed: %v", err)
+	}
+	if swept != 1 {
+		t.Fatalf("Expected 1 swept record, got %d", swept)
+	}
+
+	count, err = store.RecordCount(context.Background(), customstore.RecordQuery().
+		SetExpiredIncluded(true).
+		SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected the expired record to be deleted, 2 records left, got %d", count)
+	}
+}
+
+func TestExpireSweepSoftDelete(t *testing.T) {
+	db := InitDB("test_data_store_expire_sweep_soft_delete.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                    db,
+		TableName:             "data_expire_sweep_soft_delete",
+		AutomigrateEnabled:    true,
+		ExpiryEnabled:         true,
+		ExpirySweepSoftDelete: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ids := []string{}
+	for i := 0; i < 3; i++ {
+		record := customstore.NewRecord("cache")
+		record.SetExpiresAt(carbon.Now(carbon.UTC).SubMinutes(i + 1).ToDateTimeString(carbon.UTC))
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		ids = append(ids, record.ID())
+	}
+
+	swept, err := store.ExpireSweep(context.Background())
+	if err != nil {
+		t.Fatalf("ExpireSweep failed: %v", err)
+	}
+	if swept != 3 {
+		t.Fatalf("Expected 3 swept records, got %d", swept)
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery().
+		SetExpiredIncluded(true).
+		SetOnlySoftDeleted(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 soft deleted records, got %d", count)
+	}
+
+	swept, err = store.ExpireSweep(context.Background())
+	if err != nil {
+		t.Fatalf("ExpireSweep failed: %v", err)
+	}
+	if swept != 0 {
+		t.Fatalf("Expected the soft deleted records not to be swept again, got %d", swept)
+	}
+
+	// the swept records are in the trash, so they can be restored
+	if err := store.RecordRestoreByID(context.Background(), ids[0]); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+
+	restored, err := store.RecordFindByIDWithDeleted(context.Background(), ids[0])
+	if err != nil {
+		t.Fatalf("RecordFindByIDWithDeleted failed: %v", err)
+	}
+	if restored == nil || restored.IsSoftDeleted() {
+		t.Fatalf("Expected the expired record to be restored, got %v", restored)
+	}
+
+	// and purged
+	purged, err := store.RecordPurgeSoftDeleted(context.Background(), 0, customstore.PurgeOptions{})
+	if err != nil {
+		t.Fatalf("RecordPurgeSoftDeleted failed: %v", err)
+	}
+	if purged != 2 {
+		t.Fatalf("Expected 2 expired, soft deleted records to be purged, got %d", purged)
+	}
+}
+
+func TestExpireSweepWithExpiryDisabled(t *testing.T) {
+	db := InitDB("test_data_store_expire_sweep_disabled.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_expire_sweep_disabled",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, err := store.ExpireSweep(context.Background()); err == nil {
+		t.Fatalf("Expected error with expiry disabled, but got nil")
+	}
+
+	record := customstore.NewRecord("cache")
+	record.SetExpiresAt(carbon.Now(carbon.UTC).AddMinutes(1).ToDateTimeString(carbon.UTC))
+
+	err = store.RecordCreate(context.Background(), record)
+	if err == nil || !strings.Contains(err.Error(), "requires expiry to be enabled") {
+		t.Fatalf("Expected an error about expiry not being enabled, but got %v", err)
+	}
+}
@@ -0,0 +1,442 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/base/database"
+	"github.com/gouniverse/sb"
+	"github.com/gouniverse/uid"
+	"github.com/samber/lo"
+	"github.com/spf13/cast"
+)
+
+// RecordRevision is a previous state of a record, kept in the history table.
+// Revisions are numbered per record from 1, in the order of the changes
+type RecordRevision struct {
+	// Revision is the number of the revision
+	Revision int
+
+	// Operation is the change which replaced this state, i.e. HISTORY_OPERATION_UPDATE
+	Operation string
+
+	// ChangedAt is when the change was made
+	ChangedAt string
+
+	// Record is the state of the record before the change, nil for
+	// HISTORY_OPERATION_CREATE, as the record did not exist before it
+	Record RecordInterface
+}
+
+// RecordHistory returns the revisions of the record, oldest first
+func (st *storeImplementation) RecordHistory(ctx context.Context, id string) ([]RecordRevision, error) {
+	return st.recordRevisions(ctx, id, 0)
+}
+
+// RecordAtRevision returns the state of the record at the revision,
+// or nil if there is no such revision, or the record did not exist at it
+func (st *storeImplementation) RecordAtRevision(ctx context.Context, id string, revision int) (RecordInterface, error) {
+	if revision < 1 {
+		return nil, errors.New("revision must be greater than zero")
+	}
+
+	revisions, err := st.recordRevisions(ctx, id, revision)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisions) < 1 {
+		return nil, nil
+	}
+
+	return revisions[0].Record, nil
+}
+
+// RecordRevert reverts the record to its state at the revision. The state
+// being replaced is kept as a new revision, so a revert can be reverted too.
+// A deleted record is recreated.
+func (st *storeImplementation) RecordRevert(ctx context.Context, id string, revision int) error {
+	if revision < 1 {
+		return errors.New("revision must be greater than zero")
+	}
+
+	revisions, err := st.recordRevisions(ctx, id, revision)
+
+	if err != nil {
+		return err
+	}
+
+	if len(revisions) < 1 {
+		return errors.New("revision " + strconv.Itoa(revision) + " of record " + id + " not found")
+	}
+
+	snapshot := revisions[0].Record
+
+	if snapshot == nil {
+		return errors.New("revision " + strconv.Itoa(revision) + " of record " + id + " has no state, the record did not exist before it")
+	}
+
+	return st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		existing, exists, err := txStore.recordExisting(ctx, id)
+
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return txStore.recordRecreate(ctx, snapshot)
+		}
+
+		current := st.recordFromExistingData(existing)
+
+		for _, column := range txStore.revertableColumns() {
+			if current.Get(column) != snapshot.Get(column) {
+				current.Set(column, snapshot.Get(column))
+			}
+		}
+
+		return txStore.RecordUpdate(ctx, current)
+	})
+}
+
+// historyTableName returns the name of the history table
+func (st *storeImplementation) historyTableName() string {
+	return st.tableName + "_history"
+}
+
+// historyIndexName returns the name of the unique index on the ID and
+// the revision of the history table
+func (st *storeImplementation) historyIndexName() string {
+	return st.historyTableName() + "_id_revision"
+}
+
+// historyIndexExists returns true if the unique index of the history table
+// exists on MySQL, which can not create it only if it does not exist. On the
+// other databases the index is created only if it does not exist, so false
+// is returned
+func (st *storeImplementation) historyIndexExists(ctx context.Context) (bool, error) {
+	if st.dbDriverName != sb.DIALECT_MYSQL {
+		return false, nil
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	rows, err := database.SelectToMapString(qctx,
+		"SELECT COUNT(*) AS count FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?",
+		st.historyTableName(), st.historyIndexName())
+
+	if err != nil {
+		return false, err
+	}
+
+	return len(rows) > 0 && cast.ToInt(rows[0]["count"]) > 0, nil
+}
+
+// historySnapshot copies the current state of the records matching the
+// conditions to the history table. It must run in the transaction of the
+// write, before the write
+func (st *storeImplementation) historySnapshot(ctx context.Context, operation string, where ...exp.Expression) error {
+	if !st.historyEnabled {
+		return nil
+	}
+
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		From(st.tableName).
+		Prepared(true).
+		Where(where...).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Record history snapshot query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	rows, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return err
+	}
+
+	if len(rows) < 1 {
+		return nil
+	}
+
+	ids := lo.Map(rows, func(row map[string]string, _ int) string { return row[COLUMN_ID] })
+
+	lastRevisions, err := st.historyLastRevisions(ctx, ids)
+
+	if err != nil {
+		return err
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	historyRows := make([]any, 0, len(rows))
+	for _, row := range rows {
+		historyRow := toStoredRow(row)
+		historyRow[COLUMN_HISTORY_ID] = uid.HumanUid()
+		historyRow[COLUMN_REVISION] = lastRevisions[row[COLUMN_ID]] + 1
+		historyRow[COLUMN_OPERATION] = operation
+		historyRow[COLUMN_CHANGED_AT] = now
+		historyRows = append(historyRows, historyRow)
+	}
+
+	columnCount := len(rows[0]) + 4
+
+	for _, chunk := range lo.Chunk(historyRows, max(maxParamsPerStatement(st.dbDriverName)/columnCount, 1)) {
+		sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+			Insert(st.historyTableName()).
+			Prepared(true).
+			Rows(chunk...).
+			ToSQL()
+
+		if err != nil {
+			return err
+		}
+
+		if st.debugEnabled {
+			st.logger.Debug("Record history insert query", "query", sqlStr, "params", sqlParams)
+		}
+
+		_, err = database.Execute(qctx, sqlStr, sqlParams...)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// historyLastRevisions returns the last revision of each record in the
+// history, keyed by record ID
+func (st *storeImplementation) historyLastRevisions(ctx context.Context, ids []string) (map[string]int, error) {
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		From(st.historyTableName()).
+		Prepared(true).
+		Select(goqu.C(COLUMN_ID), goqu.MAX(COLUMN_REVISION).As(COLUMN_REVISION)).
+		Where(goqu.C(COLUMN_ID).In(ids)).
+		GroupBy(goqu.C(COLUMN_ID)).
+		ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Record history revisions query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	rows, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := map[string]int{}
+	for _, row := range rows {
+		revisions[row[COLUMN_ID]] = cast.ToInt(row[COLUMN_REVISION])
+	}
+
+	return revisions, nil
+}
+
+// recordRevisions returns the revisions of the record, oldest first,
+// or only the given revision if it is greater than zero
+func (st *storeImplementation) recordRevisions(ctx context.Context, id string, revision int) ([]RecordRevision, error) {
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if !st.historyEnabled {
+		return nil, errors.New("history is not enabled")
+	}
+
+	if id == "" {
+		return nil, errors.New("record id is empty")
+	}
+
+	where := []exp.Expression{goqu.C(COLUMN_ID).Eq(id)}
+
+	if revision > 0 {
+		where = append(where, goqu.C(COLUMN_REVISION).Eq(revision))
+	}
+
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		From(st.historyTableName()).
+		Prepared(true).
+		Where(where...).
+		Order(goqu.C(COLUMN_REVISION).Asc()).
+		ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Record history query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	rows, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]RecordRevision, 0, len(rows))
+	for _, row := range rows {
+		var record RecordInterface
+		if row[COLUMN_OPERATION] != HISTORY_OPERATION_CREATE {
+			record = st.recordFromExistingData(historyRowToRecordData(row))
+		}
+
+		revisions = append(revisions, RecordRevision{
+			Revision:  cast.ToInt(row[COLUMN_REVISION]),
+			Operation: row[COLUMN_OPERATION],
+			ChangedAt: toDateTimeValue(row[COLUMN_CHANGED_AT]),
+			Record:    record,
+		})
+	}
+
+	return revisions, nil
+}
+
+// recordRecreate inserts a deleted record back, from a snapshot of it.
+// The recreation is kept in the history, as the record did not exist
+// between its delete and now, though it keeps its created_at
+func (st *storeImplementation) recordRecreate(ctx context.Context, snapshot RecordInterface) error {
+	data := toStoredRow(snapshot.Data())
+	data[COLUMN_UPDATED_AT] = carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		Insert(st.tableName).
+		Prepared(true).
+		Rows(data).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Record recreate query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	_, err = database.Execute(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return err
+	}
+
+	// the columns of the history are not nullable, so the row holds the
+	// recreated state, which is not read
+	if err := st.historySnapshot(ctx, HISTORY_OPERATION_CREATE, goqu.C(COLUMN_ID).Eq(snapshot.ID())); err != nil {
+		return err
+	}
+
+	changed := map[string]string{}
+	for column, value := range data {
+		changed[column] = cast.ToString(value)
+	}
+
+	return st.changeLog(ctx, CHANGE_OPERATION_CREATE, changeEntry{
+		recordID:   snapshot.ID(),
+		recordType: snapshot.Type(),
+		changed:    changed,
+	})
+}
+
+// revertableColumns are the columns RecordRevert restores
+func (st *storeImplementation) revertableColumns() []string {
+	columns := []string{
+		COLUMN_RECORD_TYPE,
+		COLUMN_PAYLOAD,
+		COLUMN_METAS,
+		COLUMN_MEMO,
+		COLUMN_SOFT_DELETED_AT,
+	}
+
+	if st.expiryEnabled {
+		columns = append(columns, COLUMN_EXPIRES_AT)
+	}
+
+	if st.payloadVersioningEnabled {
+		columns = append(columns, COLUMN_PAYLOAD_VERSION)
+	}
+
+	return columns
+}
+
+// historyRowToRecordData strips the history columns from a history row,
+// leaving the data of the record
+func historyRowToRecordData(row map[string]string) map[string]string {
+	data := map[string]string{}
+
+	for column, value := range row {
+		switch column {
+		case COLUMN_HISTORY_ID, COLUMN_REVISION, COLUMN_OPERATION, COLUMN_CHANGED_AT:
+			continue
+		}
+
+		if isDateTimeColumn(column) {
+			value = toDateTimeValue(value)
+		}
+
+		data[column] = value
+	}
+
+	return data
+}
+
+// toStoredRow prepares a row read from the database to be written again,
+// normalising the datetimes, and writing empty ones as NULL
+func toStoredRow(row map[string]string) goqu.Record {
+	stored := goqu.Record{}
+
+	for column, value := range row {
+		if !isDateTimeColumn(column) {
+			stored[column] = value
+			continue
+		}
+
+		if value == "" {
+			stored[column] = nil
+			continue
+		}
+
+		stored[column] = toDateTimeValue(value)
+	}
+
+	return stored
+}
+
+// toDateTimeValue normalises a datetime read from the database to a UTC
+// datetime string, as drivers return them in different formats
+func toDateTimeValue(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return carbon.Parse(value, carbon.UTC).ToDateTimeString(carbon.UTC)
+}
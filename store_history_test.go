@@ -0,0 +1,251 @@
+package customstore_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordHistory(t *testing.T) {
+	db := InitDB("test_data_store_record_history.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_history",
+		AutomigrateEnabled: true,
+		HistoryEnabled:     true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("customer")
+	record.SetPayload(`{"name":"v1"}`)
+	if err := store.RecordCreate(context.Background(), record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	record.SetPayload(`{"name":"v2"}`)
+	if err := store.RecordUpdate(context.Background(), record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	record.SetPayload(`{"name":"v3"}`)
+	if err := store.RecordUpdate(context.Background(), record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	if err := store.RecordSoftDelete(context.Background(), record); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+
+	history, err := store.RecordHistory(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordHistory failed: %v", err)
+	}
+
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 revisions, got %d", len(history))
+	}
+
+	expected := []struct {
+		payload   string
+		operation string
+	}{
+		{`{"name":"v1"}`, customstore.HISTORY_OPERATION_UPDATE},
+		{`{"name":"v2"}`, customstore.HISTORY_OPERATION_UPDATE},
+		{`{"name":"v3"}`, customstore.HISTORY_OPERATION_SOFT_DELETE},
+	}
+
+	for i, revision := range history {
+		if revision.Revision != i+1 {
+			t.Fatalf("Expected revision %d, got %d", i+1, revision.Revision)
+		}
+		if revision.Record.Payload() != expected[i].payload {
+			t.Fatalf("Expected payload %s at revision %d, got %s", expected[i].payload, revision.Revision, revision.Record.Payload())
+		}
+		if revision.Operation != expected[i].operation {
+			t.Fatalf("Expected operation %s at revision %d, got %s", expected[i].operation, revision.Revision, revision.Operation)
+		}
+	}
+
+	atRevision, err := store.RecordAtRevision(context.Background(), record.ID(), 1)
+	if err != nil {
+		t.Fatalf("RecordAtRevision failed: %v", err)
+	}
+	if atRevision == nil || atRevision.Payload() != `{"name":"v1"}` {
+		t.Fatalf("Expected the first payload at revision 1, got %v", atRevision)
+	}
+
+	missing, err := store.RecordAtRevision(context.Background(), record.ID(), 10)
+	if err != nil {
+		t.Fatalf("RecordAtRevision failed: %v", err)
+	}
+	if missing != nil {
+		t.Fatalf("Expected nil for a missing revision")
+	}
+
+	// Revert the soft deleted record to its first state
+	if err := store.RecordRevert(context.Background(), record.ID(), 1); err != nil {
+		t.Fatalf("RecordRevert failed: %v", err)
+	}
+
+	reverted, err := store.RecordFindByID(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if reverted == nil {
+		t.Fatalf("Expected the reverted record not to be soft deleted")
+	}
+	if reverted.Payload() != `{"name":"v1"}` {
+		t.Fatalf("Expected the reverted payload, got %s", reverted.Payload())
+	}
+
+	history, err = store.RecordHistory(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordHistory failed: %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("Expected the revert to add a revision, got %d revisions", len(history))
+	}
+}
+
+func TestRecordHistoryDeleteAndRecreate(t *testing.T) {
+	db := InitDB("test_data_store_record_history_delete.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_history_delete",
+		AutomigrateEnabled: true,
+		HistoryEnabled:     true,
+		VersioningEnabled:  true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{}
+	for i := 0; i < 3; i++ {
+		record := customstore.NewRecord("customer")
+		record.SetMemo("memo")
+		if err := store.RecordCreate(context.Background(), record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	if err := store.RecordDelete(context.Background(), records[0]); err != nil {
+		t.Fatalf("RecordDelete failed: %v", err)
+	}
+
+	if err := store.RecordDeleteByIDs(context.Background(), []string{records[1].ID(), records[2].ID()}); err != nil {
+		t.Fatalf("RecordDeleteByIDs failed: %v", err)
+	}
+
+	for _, record := range records {
+		history, err := store.RecordHistory(context.Background(), record.ID())
+		if err != nil {
+			t.Fatalf("RecordHistory failed: %v", err)
+		}
+		if len(history) != 1 || history[0].Operation != customstore.HISTORY_OPERATION_DELETE {
+			t.Fatalf("Expected a single delete revision, got %d", len(history))
+		}
+	}
+
+	if err := store.RecordRevert(context.Background(), records[0].ID(), 1); err != nil {
+		t.Fatalf("RecordRevert failed: %v", err)
+	}
+
+	recreated, err := store.RecordFindByID(context.Background(), records[0].ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if recreated == nil || recreated.Memo() != "memo" {
+		t.Fatalf("Expected the deleted record to be recreated")
+	}
+
+	if err := store.RecordRevert(context.Background(), records[0].ID(), 5); err == nil {
+		t.Fatalf("Expected error reverting to a missing revision, but got nil")
+	}
+}
+
+func TestRecordHistoryWithHistoryDisabled(t *testing.T) {
+	db := InitDB("test_data_store_record_history_disabled.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_history_disabled",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, err := store.RecordHistory(context.Background(), "1"); err == nil {
+		t.Fatalf("Expected error with history disabled, but got nil")
+	}
+}
+
+func TestRecordRevertWithExpiry(t *testing.T) {
+	db := InitDB("test_data_store_record_revert_expiry.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_revert_expiry",
+		AutomigrateEnabled: true,
+		HistoryEnabled:     true,
+		ExpiryEnabled:      true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	record := customstore.NewRecord("person")
+	record.SetPayload(`{"name":"Ann"}`)
+	if err := store.RecordCreate(ctx, record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	record.SetPayload(`{"name":"Bob"}`)
+	if err := store.RecordUpdate(ctx, record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	if err := store.RecordRevert(ctx, record.ID(), 1); err != nil {
+		t.Fatalf("RecordRevert failed: %v", err)
+	}
+
+	// a record without expiry keeps a NULL expires_at, not ''
+	var expiresAt sql.NullString
+	if err := db.QueryRow(`SELECT expires_at FROM data_record_revert_expiry WHERE id = ?`, record.ID()).Scan(&expiresAt); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if expiresAt.Valid {
+		t.Fatalf("Expected expires_at to be NULL, but got %q", expiresAt.String)
+	}
+
+	// the revisions are unique per record
+	if err := store.AutoMigrate(ctx); err != nil {
+		t.Fatalf("AutoMigrate failed a second time: %v", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO data_record_revert_expiry_history (history_id, id, revision, operation) VALUES (?, ?, 1, ?)`,
+		"duplicate", record.ID(), customstore.HISTORY_OPERATION_UPDATE)
+
+	if err == nil {
+		t.Fatal("Expected a duplicate revision to be rejected")
+	}
+}
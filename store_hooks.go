@@ -0,0 +1,111 @@
+package customstore
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// HookFunc is a lifecycle hook. It receives the record and its changed
+// fields, as returned by DataChanged (empty for deletes). An error returned
+// by a before hook aborts the operation. An error returned by an after hook
+// is returned by the operation, which is carried out already, unless it ran
+// in a transaction (i.e. a batch) which the error then rolls back
+type HookFunc func(ctx context.Context, record RecordInterface, changed map[string]string) error
+
+// Lifecycle events the hooks are registered for
+const (
+	hookBeforeCreate = "before_create"
+	hookAfterCreate  = "after_create"
+	hookBeforeUpdate = "before_update"
+	hookAfterUpdate  = "after_update"
+	hookBeforeDelete = "before_delete"
+	hookAfterDelete  = "after_delete"
+)
+
+// registeredHook is a hook, with the record types it is limited to
+type registeredHook struct {
+	fn          HookFunc
+	recordTypes []string
+}
+
+// hookRegistry is the registry of the lifecycle hooks, by event
+type hookRegistry struct {
+	mu    sync.RWMutex
+	hooks map[string][]registeredHook
+}
+
+// OnBeforeCreate registers a hook run before a record is created, for the
+// given record types only if any. The hook may modify the record
+func (st *storeImplementation) OnBeforeCreate(hook HookFunc, recordTypes ...string) {
+	st.hookAdd(hookBeforeCreate, hook, recordTypes)
+}
+
+// OnAfterCreate registers a hook run after a record is created
+func (st *storeImplementation) OnAfterCreate(hook HookFunc, recordTypes ...string) {
+	st.hookAdd(hookAfterCreate, hook, recordTypes)
+}
+
+// OnBeforeUpdate registers a hook run before a record is updated, including
+// when soft deleted or restored. The hook may modify the record
+func (st *storeImplementation) OnBeforeUpdate(hook HookFunc, recordTypes ...string) {
+	st.hookAdd(hookBeforeUpdate, hook, recordTypes)
+}
+
+// OnAfterUpdate registers a hook run after a record is updated
+func (st *storeImplementation) OnAfterUpdate(hook HookFunc, recordTypes ...string) {
+	st.hookAdd(hookAfterUpdate, hook, recordTypes)
+}
+
+// OnBeforeDelete registers a hook run before a record is deleted
+func (st *storeImplementation) OnBeforeDelete(hook HookFunc, recordTypes ...string) {
+	st.hookAdd(hookBeforeDelete, hook, recordTypes)
+}
+
+// OnAfterDelete registers a hook run after a record is deleted
+func (st *storeImplementation) OnAfterDelete(hook HookFunc, recordTypes ...string) {
+	st.hookAdd(hookAfterDelete, hook, recordTypes)
+}
+
+func (st *storeImplementation) hookAdd(event string, hook HookFunc, recordTypes []string) {
+	if hook == nil {
+		return
+	}
+
+	st.hooks.mu.Lock()
+	defer st.hooks.mu.Unlock()
+
+	st.hooks.hooks[event] = append(st.hooks.hooks[event], registeredHook{
+		fn:          hook,
+		recordTypes: recordTypes,
+	})
+}
+
+// hookExists returns true if any hook is registered for the event
+func (st *storeImplementation) hookExists(event string) bool {
+	st.hooks.mu.RLock()
+	defer st.hooks.mu.RUnlock()
+
+	return len(st.hooks.hooks[event]) > 0
+}
+
+// hookRun runs the hooks registered for the event and the type of the
+// record, in the order registered, stopping at the first error
+func (st *storeImplementation) hookRun(ctx context.Context, event string, record RecordInterface, changed map[string]string) error {
+	st.hooks.mu.RLock()
+	hooks := slices.Clone(st.hooks.hooks[event])
+	st.hooks.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if len(hook.recordTypes) > 0 && !slices.Contains(hook.recordTypes, record.Type()) {
+			continue
+		}
+
+		if err := hook.fn(ctx, record, maps.Clone(changed)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestStoreHooks(t *testing.T) {
+	db := InitDB("test_data_store_hooks.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_hooks",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	events := []string{}
+	logEvent := func(event string) customstore.HookFunc {
+		return func(ctx context.Context, record customstore.RecordInterface, changed map[string]string) error {
+			events = append(events, event+":"+record.Type())
+			return nil
+		}
+	}
+
+	// normalises the payload and stamps an audit meta
+	store.OnBeforeCreate(func(ctx context.Context, record customstore.RecordInterface, changed map[string]string) error {
+		record.SetPayload(strings.TrimSpace(record.Payload()))
+		return record.SetMeta("created_by", "hook")
+	}, "person")
+
+	store.OnAfterCreate(logEvent("after_create"))
+	store.OnBeforeUpdate(func(ctx context.Context, record customstore.RecordInterface, changed map[string]string) error {
+		if _, isChanged := changed[customstore.COLUMN_PAYLOAD]; isChanged {
+			events = append(events, "before_update:payload")
+		}
+		return nil
+	})
+	store.OnAfterUpdate(logEvent("after_update"), "person")
+	store.OnBeforeDelete(logEvent("before_delete"))
+	store.OnAfterDelete(logEvent("after_delete"))
+
+	person := customstore.NewRecord("person")
+	person.SetPayload("  {}  ")
+	if err := store.RecordCreate(context.Background(), person); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	stored, err := store.RecordFindByID(context.Background(), person.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if stored.Payload() != "{}" || stored.Meta("created_by") != "hook" {
+		t.Fatalf("Expected the before create hook changes to be stored, got payload %q and meta %q", stored.Payload(), stored.Meta("created_by"))
+	}
+
+	company := customstore.NewRecord("company")
+	if err := store.RecordCreate(context.Background(), company); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if company.Meta("created_by") != "" {
+		t.Fatalf("Expected the person hook not to run for a company")
+	}
+
+	person.SetPayload(`{"name":"Jon"}`)
+	if err := store.RecordUpdate(context.Background(), person); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	company.SetMemo("memo")
+	if err := store.RecordUpdate(context.Background(), company); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	if err := store.RecordDeleteByIDs(context.Background(), []string{person.ID(), company.ID()}); err != nil {
+		t.Fatalf("RecordDeleteByIDs failed: %v", err)
+	}
+
+	expected := []string{
+		"after_create:person",
+		"after_create:company",
+		"before_update:payload",
+		"after_update:person",
+		"before_delete:person",
+		"after_delete:person",
+		"before_delete:company",
+		"after_delete:company",
+	}
+
+	if strings.Join(events, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected events %v, got %v", expected, events)
+	}
+}
+
+func TestStoreBeforeHookAbortsOperation(t *testing.T) {
+	db := InitDB("test_data_store_hooks_abort.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_hooks_abort",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	errReadOnly := errors.New("record is read only")
+
+	store.OnBeforeCreate(func(ctx context.Context, record customstore.RecordInterface, changed map[string]string) error {
+		if record.Memo() == "invalid" {
+			return errors.New("memo is invalid")
+		}
+		return nil
+	})
+
+	store.OnBeforeUpdate(func(ctx context.Context, record customstore.RecordInterface, changed map[string]string) error {
+		return errReadOnly
+	}, "locked")
+
+	store.OnBeforeDelete(func(ctx context.Context, record customstore.RecordInterface, changed map[string]string) error {
+		return errReadOnly
+	}, "locked")
+
+	invalid := customstore.NewRecord("person")
+	invalid.SetMemo("invalid")
+	if err := store.RecordCreate(context.Background(), invalid); err == nil {
+		t.Fatalf("Expected the before create hook to abort the create")
+	}
+
+	if err := store.RecordCreateMany(context.Background(), []customstore.RecordInterface{
+		customstore.NewRecord("person"),
+		invalid,
+	}); err == nil {
+		t.Fatalf("Expected the before create hook to abort the batch create")
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no records to be created, got %d", count)
+	}
+
+	locked := customstore.NewRecord("locked")
+	if err := store.RecordCreate(context.Background(), locked); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	locked.SetMemo("changed")
+	if err := store.RecordUpdate(context.Background(), locked); !errors.Is(err, errReadOnly) {
+		t.Fatalf("Expected the update to be aborted, got %v", err)
+	}
+
+	if err := store.RecordSoftDeleteByIDs(context.Background(), []string{locked.ID()}); !errors.Is(err, errReadOnly) {
+		t.Fatalf("Expected the soft delete to be aborted, got %v", err)
+	}
+
+	if err := store.RecordDeleteByID(context.Background(), locked.ID()); !errors.Is(err, errReadOnly) {
+		t.Fatalf("Expected the delete to be aborted, got %v", err)
+	}
+
+	found, err := store.RecordFindByID(context.Background(), locked.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found == nil || found.Memo() != "" {
+		t.Fatalf("Expected the locked record to be unchanged")
+	}
+}
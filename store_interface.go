@@ -1,38 +1,157 @@
 package customstore
 
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
 // StoreInterface defines a custom store
 
 type StoreInterface interface {
 	// AutoMigrate migrates the tables
-	AutoMigrate() error
+	AutoMigrate(ctx context.Context) error
+
+	// ChangeCheckpointGet returns the last sequence the consumer of the change feed processed
+	ChangeCheckpointGet(ctx context.Context, consumer string) (int64, error)
+
+	// ChangeCheckpointSet keeps the last sequence the consumer of the change feed processed
+	ChangeCheckpointSet(ctx context.Context, consumer string, sequence int64) error
+
+	// ChangesSince returns the changes after the sequence, oldest first, when changes are enabled
+	ChangesSince(ctx context.Context, sequence int64, limit int) ([]Change, error)
+
+	// ExpireSweep deletes, or soft deletes, the expired records, returning the number swept
+	ExpireSweep(ctx context.Context) (int64, error)
 
 	// EnableDebug - enables the debug option
 	EnableDebug(debug bool)
 
+	// OnBeforeCreate registers a hook run before a record is created, optionally for the given record types only
+	OnBeforeCreate(hook HookFunc, recordTypes ...string)
+
+	// OnAfterCreate registers a hook run after a record is created
+	OnAfterCreate(hook HookFunc, recordTypes ...string)
+
+	// OnBeforeUpdate registers a hook run before a record is updated (soft deletes and restores included)
+	OnBeforeUpdate(hook HookFunc, recordTypes ...string)
+
+	// OnAfterUpdate registers a hook run after a record is updated
+	OnAfterUpdate(hook HookFunc, recordTypes ...string)
+
+	// OnBeforeDelete registers a hook run before a record is deleted
+	OnBeforeDelete(hook HookFunc, recordTypes ...string)
+
+	// OnAfterDelete registers a hook run after a record is deleted
+	OnAfterDelete(hook HookFunc, recordTypes ...string)
+
+	// RecordAtRevision returns the state of the record at the revision, when history is enabled
+	RecordAtRevision(ctx context.Context, id string, revision int) (RecordInterface, error)
+
 	// RecordCount returns the count of records based on a query
-	RecordCount(query RecordQueryInterface) (int64, error)
+	RecordCount(ctx context.Context, query RecordQueryInterface) (int64, error)
 
 	// RecordCreate creates a new record
-	RecordCreate(record RecordInterface) error
+	RecordCreate(ctx context.Context, record RecordInterface) error
+
+	// RecordCreateMany creates the records in a single transaction
+	RecordCreateMany(ctx context.Context, records []RecordInterface) error
 
 	// RecordDelete deletes a record
-	RecordDelete(record RecordInterface) error
+	RecordDelete(ctx context.Context, record RecordInterface) error
 
 	// RecordDeleteByID deletes a record by ID
-	RecordDeleteByID(id string) error
+	RecordDeleteByID(ctx context.Context, id string) error
+
+	// RecordDeleteByIDs deletes the records with the given IDs in a single transaction
+	RecordDeleteByIDs(ctx context.Context, ids []string) error
+
+	// RecordDeleteByQuery deletes the records matching the query, returning the number deleted
+	RecordDeleteByQuery(ctx context.Context, query RecordQueryInterface) (int64, error)
 
 	// RecordFindByID finds a record by ID
-	RecordFindByID(id string) (RecordInterface, error)
+	RecordFindByID(ctx context.Context, id string) (RecordInterface, error)
+
+	// RecordFindByIDAsOf finds a record by ID as it was at the instant, when history is enabled
+	RecordFindByIDAsOf(ctx context.Context, id string, asOf any) (RecordInterface, error)
+
+	// RecordFindByIDWithDeleted finds a record by ID, including a soft deleted one, expired or not
+	RecordFindByIDWithDeleted(ctx context.Context, id string) (RecordInterface, error)
+
+	// RecordHistory returns the revisions of the record, oldest first, when history is enabled
+	RecordHistory(ctx context.Context, id string) ([]RecordRevision, error)
 
 	// RecordList returns a list of records
-	RecordList(query RecordQueryInterface) ([]RecordInterface, error)
+	RecordList(ctx context.Context, query RecordQueryInterface) ([]RecordInterface, error)
+
+	// RecordListPage returns a page of records using keyset (cursor) pagination
+	RecordListPage(ctx context.Context, query RecordQueryInterface) (RecordPage, error)
+
+	// RecordPurgeByRetentionPolicies purges the soft deleted records of each record type with a retention policy
+	RecordPurgeByRetentionPolicies(ctx context.Context, batchSize int) (map[string]int64, error)
+
+	// RecordPurgeSoftDeleted hard deletes, in batches, the records soft deleted longer ago than olderThan
+	RecordPurgeSoftDeleted(ctx context.Context, olderThan time.Duration, opts PurgeOptions) (int64, error)
+
+	// RecordRestore restores a soft deleted record
+	RecordRestore(ctx context.Context, record RecordInterface) error
+
+	// RecordRestoreByID restores a soft deleted record by ID
+	RecordRestoreByID(ctx context.Context, id string) error
+
+	// RecordRevert reverts the record to its state at the revision, when history is enabled
+	RecordRevert(ctx context.Context, id string, revision int) error
 
 	// RecordSoftDelete soft deletes a record
-	RecordSoftDelete(record RecordInterface) error
+	RecordSoftDelete(ctx context.Context, record RecordInterface) error
 
 	// RecordSoftDeleteByID soft deletes a record by ID
-	RecordSoftDeleteByID(id string) error
+	RecordSoftDeleteByID(ctx context.Context, id string) error
+
+	// RecordSoftDeleteByIDs soft deletes the records with the given IDs in a single transaction
+	RecordSoftDeleteByIDs(ctx context.Context, ids []string) error
+
+	// RecordSoftDeleteByQuery soft deletes the records matching the query, returning the number soft deleted
+	RecordSoftDeleteByQuery(ctx context.Context, query RecordQueryInterface) (int64, error)
 
 	// RecordUpdate updates a record
-	RecordUpdate(record RecordInterface) error
+	RecordUpdate(ctx context.Context, record RecordInterface) error
+
+	// RecordUpdateMany updates the records in a single transaction
+	RecordUpdateMany(ctx context.Context, records []RecordInterface) error
+
+	// RecordUpsert inserts the record, or updates it if it already exists,
+	// and reports whether it was inserted
+	RecordUpsert(ctx context.Context, record RecordInterface) (inserted bool, err error)
+
+	// RegisterSchema registers the JSON Schema the payloads of the record type are validated against on create and update
+	RegisterSchema(recordType string, schema string) error
+
+	// RetentionPolicyList returns the retention policies, by record type
+	RetentionPolicyList() map[string]time.Duration
+
+	// RetentionPolicyRemove removes the retention policy of the record type
+	RetentionPolicyRemove(recordType string)
+
+	// RetentionPolicySet sets how long soft deleted records of the record type are kept
+	RetentionPolicySet(recordType string, retention time.Duration) error
+
+	// Subscribe returns a channel receiving the changes of the records after they are committed, and a cancel function
+	Subscribe(opts SubscribeOptions) (<-chan ChangeEvent, func())
+
+	// UnregisterSchema removes the schema of the record type
+	UnregisterSchema(recordType string)
+
+	// ValidatePayload validates the payload against the schema of the record type, without writing anything
+	ValidatePayload(recordType string, payload string) error
+
+	// UpgradeAll migrates the payloads of all the records of the type to the latest version, returning the number upgraded
+	UpgradeAll(ctx context.Context, recordType string) (int64, error)
+
+	// WithTx runs the callback inside a transaction, committing on success
+	// and rolling back on error or panic
+	WithTx(ctx context.Context, fn func(tx StoreInterface) error) error
+
+	// WithExternalTx returns a store bound to a transaction started elsewhere
+	WithExternalTx(tx *sql.Tx) StoreInterface
 }
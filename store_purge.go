@@ -0,0 +1,161 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// defaultPurgeBatchSize is the number of records deleted per batch,
+// when PurgeOptions.BatchSize is not set
+const defaultPurgeBatchSize = 1000
+
+// PurgeOptions define the options for purging soft deleted records
+type PurgeOptions struct {
+	// RecordTypes limits the purge to the given record types,
+	// all record types are purged when empty
+	RecordTypes []string
+
+	// BatchSize is the number of records deleted per batch (transaction),
+	// defaults to 1000
+	BatchSize int
+}
+
+// retentionPolicies is the registry of retention periods, by record type
+type retentionPolicies struct {
+	mu       sync.RWMutex
+	policies map[string]time.Duration
+}
+
+// RecordPurgeSoftDeleted hard deletes the records soft deleted longer ago
+// than olderThan, in batches, and returns the number of deleted records
+func (st *storeImplementation) RecordPurgeSoftDeleted(ctx context.Context, olderThan time.Duration, opts PurgeOptions) (int64, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if olderThan < 0 {
+		return 0, errors.New("olderThan must not be negative")
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = defaultPurgeBatchSize
+	}
+
+	softDeletedBefore := carbon.CreateFromStdTime(time.Now().UTC().Add(-olderThan))
+
+	var purged int64
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+
+		query := RecordQuery().
+			SetColumns([]string{COLUMN_ID}).
+			SetOnlySoftDeleted(true).
+			SetSoftDeletedAtLte(softDeletedBefore).
+			SetExpiredIncluded(true). // expired, soft deleted records go too
+			SetLimit(batchSize)
+
+		if len(opts.RecordTypes) > 0 {
+			query.SetTypeIn(opts.RecordTypes)
+		}
+
+		records, err := st.RecordList(ctx, query)
+
+		if err != nil {
+			return purged, err
+		}
+
+		ids := make([]string, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record.ID())
+		}
+
+		if err := st.RecordDeleteByIDs(ctx, ids); err != nil {
+			return purged, err
+		}
+
+		purged += int64(len(ids))
+
+		if len(ids) < batchSize {
+			return purged, nil
+		}
+	}
+}
+
+// RetentionPolicySet sets how long soft deleted records of the given type
+// are kept, before RecordPurgeByRetentionPolicies hard deletes them
+func (st *storeImplementation) RetentionPolicySet(recordType string, retention time.Duration) error {
+	if recordType == "" {
+		return errors.New("record type is empty")
+	}
+
+	if retention < 0 {
+		return errors.New("retention must not be negative")
+	}
+
+	st.retention.mu.Lock()
+	defer st.retention.mu.Unlock()
+
+	st.retention.policies[recordType] = retention
+
+	return nil
+}
+
+// RetentionPolicyRemove removes the retention policy of the record type
+func (st *storeImplementation) RetentionPolicyRemove(recordType string) {
+	st.retention.mu.Lock()
+	defer st.retention.mu.Unlock()
+
+	delete(st.retention.policies, recordType)
+}
+
+// RetentionPolicyList returns the retention policies, by record type
+func (st *storeImplementation) RetentionPolicyList() map[string]time.Duration {
+	st.retention.mu.RLock()
+	defer st.retention.mu.RUnlock()
+
+	policies := make(map[string]time.Duration, len(st.retention.policies))
+	for recordType, retention := range st.retention.policies {
+		policies[recordType] = retention
+	}
+
+	return policies
+}
+
+// RecordPurgeByRetentionPolicies purges the soft deleted records of each
+// record type with a retention policy, and returns the number of deleted
+// records by record type. Meant to be run by a periodic job
+func (st *storeImplementation) RecordPurgeByRetentionPolicies(ctx context.Context, batchSize int) (map[string]int64, error) {
+	policies := st.RetentionPolicyList()
+
+	recordTypes := make([]string, 0, len(policies))
+	for recordType := range policies {
+		recordTypes = append(recordTypes, recordType)
+	}
+	sort.Strings(recordTypes)
+
+	purged := map[string]int64{}
+
+	for _, recordType := range recordTypes {
+		count, err := st.RecordPurgeSoftDeleted(ctx, policies[recordType], PurgeOptions{
+			RecordTypes: []string{recordType},
+			BatchSize:   batchSize,
+		})
+
+		purged[recordType] = count
+
+		if err != nil {
+			return purged, err
+		}
+	}
+
+	return purged, nil
+}
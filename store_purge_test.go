@@ -0,0 +1,135 @@
+package customstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/customstore"
+)
+
+func createSoftDeletedRecord(t *testing.T, store customstore.StoreInterface, recordType string, softDeletedAt string) {
+	t.Helper()
+
+	record := customstore.NewRecord(recordType)
+	record.SetSoftDeletedAt(softDeletedAt)
+
+	if err := store.RecordCreate(context.Background(), record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+}
+
+func TestRecordPurgeSoftDeleted(t *testing.T) {
+	db := InitDB("test_data_store_record_purge_soft_deleted.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_purge_soft_deleted",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	old := carbon.Now(carbon.UTC).SubDays(30).ToDateTimeString(carbon.UTC)
+	recent := carbon.Now(carbon.UTC).SubHours(1).ToDateTimeString(carbon.UTC)
+
+	for i := 0; i < 5; i++ {
+		createSoftDeletedRecord(t, store, "session", old)
+	}
+	createSoftDeletedRecord(t, store, "session", recent)
+	createSoftDeletedRecord(t, store, "invoice", old)
+
+	if err := store.RecordCreate(context.Background(), customstore.NewRecord("session")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	purged, err := store.RecordPurgeSoftDeleted(context.Background(), 7*24*time.Hour, customstore.PurgeOptions{
+		RecordTypes: []string{"session"},
+		BatchSize:   2,
+	})
+
+	if err != nil {
+		t.Fatalf("RecordPurgeSoftDeleted failed: %v", err)
+	}
+
+	if purged != 5 {
+		t.Fatalf("Expected 5 purged records, got %d", purged)
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery().SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+
+	if count != 3 {
+		t.Fatalf("Expected 3 records left (recent session, invoice, live session), got %d", count)
+	}
+
+	if _, err := store.RecordPurgeSoftDeleted(context.Background(), -time.Hour, customstore.PurgeOptions{}); err == nil {
+		t.Fatalf("Expected error for a negative olderThan, but got nil")
+	}
+}
+
+func TestRecordPurgeByRetentionPolicies(t *testing.T) {
+	db := InitDB("test_data_store_record_purge_retention.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_purge_retention",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	createSoftDeletedRecord(t, store, "session", carbon.Now(carbon.UTC).SubDays(8).ToDateTimeString(carbon.UTC))
+	createSoftDeletedRecord(t, store, "session", carbon.Now(carbon.UTC).SubDays(2).ToDateTimeString(carbon.UTC))
+	createSoftDeletedRecord(t, store, "invoice", carbon.Now(carbon.UTC).SubYears(2).ToDateTimeString(carbon.UTC))
+	createSoftDeletedRecord(t, store, "note", carbon.Now(carbon.UTC).SubYears(20).ToDateTimeString(carbon.UTC))
+
+	if err := store.RetentionPolicySet("session", 7*24*time.Hour); err != nil {
+		t.Fatalf("RetentionPolicySet failed: %v", err)
+	}
+
+	if err := store.RetentionPolicySet("invoice", 7*365*24*time.Hour); err != nil {
+		t.Fatalf("RetentionPolicySet failed: %v", err)
+	}
+
+	if err := store.RetentionPolicySet("", time.Hour); err == nil {
+		t.Fatalf("Expected error for an empty record type, but got nil")
+	}
+
+	if len(store.RetentionPolicyList()) != 2 {
+		t.Fatalf("Expected 2 retention policies, got %d", len(store.RetentionPolicyList()))
+	}
+
+	purged, err := store.RecordPurgeByRetentionPolicies(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("RecordPurgeByRetentionPolicies failed: %v", err)
+	}
+
+	if purged["session"] != 1 || purged["invoice"] != 0 {
+		t.Fatalf("Expected 1 session and 0 invoices purged, got %v", purged)
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery().SetOnlySoftDeleted(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+
+	if count != 3 {
+		t.Fatalf("Expected 3 soft deleted records left (the note has no policy), got %d", count)
+	}
+
+	store.RetentionPolicyRemove("session")
+
+	if len(store.RetentionPolicyList()) != 1 {
+		t.Fatalf("Expected 1 retention policy after remove, got %d", len(store.RetentionPolicyList()))
+	}
+}
@@ -0,0 +1,131 @@
+package customstore
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ValidationError is returned when a payload is not valid against the
+// schema of its record type, with every issue found
+type ValidationError struct {
+	RecordType string
+	Issues     []ValidationIssue
+}
+
+// ValidationIssue is an issue with a payload
+type ValidationIssue struct {
+	// Path is the path of the field, i.e. "address.city" or "tags[0]",
+	// empty for the payload itself
+	Path string
+
+	// Rule is the rule the field breaks, i.e. SCHEMA_RULE_REQUIRED
+	Rule string
+
+	// Message describes the issue
+	Message string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		if issue.Path == "" {
+			messages = append(messages, issue.Message)
+			continue
+		}
+
+		messages = append(messages, issue.Path+" "+issue.Message)
+	}
+
+	return "payload of " + e.RecordType + " is not valid (" + strconv.Itoa(len(e.Issues)) + " issue(s)): " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) add(path string, rule string, message string) {
+	e.Issues = append(e.Issues, ValidationIssue{
+		Path:    path,
+		Rule:    rule,
+		Message: message,
+	})
+}
+
+// schemaRegistry is the registry of the payload schemas, by record type
+type schemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string]*jsonSchema
+}
+
+// RegisterSchema registers the JSON Schema the payloads of the record type
+// must be valid against, replacing the previous one. The records are then
+// validated on create and update, which fail with a *ValidationError
+func (st *storeImplementation) RegisterSchema(recordType string, schema string) error {
+	if recordType == "" {
+		return errors.New("record type is empty")
+	}
+
+	compiled, err := compileJSONSchema(schema)
+
+	if err != nil {
+		return err
+	}
+
+	st.schemas.mu.Lock()
+	defer st.schemas.mu.Unlock()
+
+	st.schemas.schemas[recordType] = compiled
+
+	return nil
+}
+
+// UnregisterSchema removes the schema of the record type
+func (st *storeImplementation) UnregisterSchema(recordType string) {
+	st.schemas.mu.Lock()
+	defer st.schemas.mu.Unlock()
+
+	delete(st.schemas.schemas, recordType)
+}
+
+// ValidatePayload validates the payload against the schema of the record
+// type, without writing anything. It returns a *ValidationError if the
+// payload is not valid, and nil if it is or there is no schema for the type
+func (st *storeImplementation) ValidatePayload(recordType string, payload string) error {
+	st.schemas.mu.RLock()
+	schema, exists := st.schemas.schemas[recordType]
+	st.schemas.mu.RUnlock()
+
+	if !exists {
+		return nil
+	}
+
+	result := &ValidationError{RecordType: recordType}
+
+	var value any
+
+	if err := json.Unmarshal([]byte(payload), &value); err != nil {
+		result.add("", SCHEMA_RULE_JSON, "must be valid JSON")
+		return result
+	}
+
+	schema.validate(value, "", result)
+
+	if len(result.Issues) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+// schemaValidateRecord validates the payload of the record, if its payload
+// or type changed
+func (st *storeImplementation) schemaValidateRecord(record RecordInterface, changed map[string]string) error {
+	_, isPayloadChanged := changed[COLUMN_PAYLOAD]
+	_, isTypeChanged := changed[COLUMN_RECORD_TYPE]
+
+	if !isPayloadChanged && !isTypeChanged {
+		return nil
+	}
+
+	return st.ValidatePayload(record.Type(), record.Payload())
+}
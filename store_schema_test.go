@@ -0,0 +1,248 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+const storeSchemaCustomer = `{
+	"type": "object",
+	"required": ["name", "email"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "maxLength": 50},
+		"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+		"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+		"status": {"enum": ["active", "inactive"]},
+		"address": {
+			"type": "object",
+			"required": ["city"],
+			"properties": {"city": {"type": "string"}}
+		},
+		"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+	}
+}`
+
+func TestStoreValidatePayload(t *testing.T) {
+	db := InitDB("test_data_store_validate_payload.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_validate_payload",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.RegisterSchema("customer", storeSchemaCustomer); err != nil {
+		t.Fatalf("RegisterSchema failed: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		payload string
+		issues  []customstore.ValidationIssue
+	}{
+		{
+			name:    "valid",
+			payload: `{"name":"Ann","email":"ann@example.com","age":30,"status":"active","address":{"city":"London"},"tags":["vip"]}`,
+		},
+		{
+			name:    "invalid json",
+			payload: `{"name":`,
+			issues:  []customstore.ValidationIssue{{Path: "", Rule: customstore.SCHEMA_RULE_JSON}},
+		},
+		{
+			name:    "not an object",
+			payload: `[]`,
+			issues:  []customstore.ValidationIssue{{Path: "", Rule: customstore.SCHEMA_RULE_TYPE}},
+		},
+		{
+			name:    "required",
+			payload: `{"name":"Ann"}`,
+			issues:  []customstore.ValidationIssue{{Path: "email", Rule: customstore.SCHEMA_RULE_REQUIRED}},
+		},
+		{
+			name:    "string rules",
+			payload: `{"name":"","email":"ann"}`,
+			issues: []customstore.ValidationIssue{
+				{Path: "email", Rule: customstore.SCHEMA_RULE_PATTERN},
+				{Path: "name", Rule: customstore.SCHEMA_RULE_MIN_LENGTH},
+			},
+		},
+		{
+			name:    "number rules",
+			payload: `{"name":"Ann","email":"ann@example.com","age":150}`,
+			issues:  []customstore.ValidationIssue{{Path: "age", Rule: customstore.SCHEMA_RULE_EXCLUSIVE_MAXIMUM}},
+		},
+		{
+			name:    "integer",
+			payload: `{"name":"Ann","email":"ann@example.com","age":30.5}`,
+			issues:  []customstore.ValidationIssue{{Path: "age", Rule: customstore.SCHEMA_RULE_TYPE}},
+		},
+		{
+			name:    "enum",
+			payload: `{"name":"Ann","email":"ann@example.com","status":"deleted"}`,
+			issues:  []customstore.ValidationIssue{{Path: "status", Rule: customstore.SCHEMA_RULE_ENUM}},
+		},
+		{
+			name:    "nested",
+			payload: `{"name":"Ann","email":"ann@example.com","address":{},"tags":["a",1,"c"]}`,
+			issues: []customstore.ValidationIssue{
+				{Path: "address.city", Rule: customstore.SCHEMA_RULE_REQUIRED},
+				{Path: "tags", Rule: customstore.SCHEMA_RULE_MAX_ITEMS},
+				{Path: "tags[1]", Rule: customstore.SCHEMA_RULE_TYPE},
+			},
+		},
+		{
+			name:    "additional properties",
+			payload: `{"name":"Ann","email":"ann@example.com","nickname":"A"}`,
+			issues:  []customstore.ValidationIssue{{Path: "nickname", Rule: customstore.SCHEMA_RULE_ADDITIONAL_PROPERTIES}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := store.ValidatePayload("customer", testCase.payload)
+
+			if len(testCase.issues) == 0 {
+				if err != nil {
+					t.Fatalf("Expected the payload to be valid, got %v", err)
+				}
+				return
+			}
+
+			var validationErr *customstore.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected a *ValidationError, got %v", err)
+			}
+
+			if len(validationErr.Issues) != len(testCase.issues) {
+				t.Fatalf("Expected %d issues, got %+v", len(testCase.issues), validationErr.Issues)
+			}
+
+			for index, issue := range testCase.issues {
+				actual := validationErr.Issues[index]
+				if actual.Path != issue.Path || actual.Rule != issue.Rule || actual.Message == "" {
+					t.Fatalf("Expected issue #%d to be %s at %q, got %+v", index, issue.Rule, issue.Path, actual)
+				}
+			}
+		})
+	}
+
+	// no schema for the type
+	if err := store.ValidatePayload("supplier", "not json"); err != nil {
+		t.Fatalf("Expected no validation without a schema, got %v", err)
+	}
+
+	if err := store.RegisterSchema("supplier", `{"type": "unknown"}`); err == nil {
+		t.Fatal("Expected RegisterSchema to reject an invalid schema")
+	}
+
+	if err := store.RegisterSchema("supplier", `{"properties": {"code": {"pattern": "("}}}`); err == nil {
+		t.Fatal("Expected RegisterSchema to reject an invalid pattern")
+	}
+
+	unsupported := []string{
+		`{"oneOf": [{"type": "string"}, {"type": "integer"}]}`,
+		`{"properties": {"address": {"$ref": "#/$defs/address"}}}`,
+		`{"properties": {"email": {"type": "string", "format": "email"}}}`,
+		`{"type": "array", "items": {"type": "integer", "multipleOf": 2}, "uniqueItems": true}`,
+	}
+
+	for _, schema := range unsupported {
+		if err := store.RegisterSchema("supplier", schema); err == nil || !strings.Contains(err.Error(), "is not supported") {
+			t.Fatalf("Expected RegisterSchema to reject the unsupported keyword of %s, got %v", schema, err)
+		}
+	}
+
+	annotated := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "Supplier",
+		"description": "A supplier",
+		"properties": {"code": {"type": "string", "description": "The code", "examples": ["S1"]}}
+	}`
+
+	if err := store.RegisterSchema("supplier", annotated); err != nil {
+		t.Fatalf("Expected RegisterSchema to allow the annotations, got %v", err)
+	}
+}
+
+func TestStoreSchemaValidation(t *testing.T) {
+	db := InitDB("test_data_store_schema_validation.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_schema_validation",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.RegisterSchema("customer", storeSchemaCustomer); err != nil {
+		t.Fatalf("RegisterSchema failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	var validationErr *customstore.ValidationError
+
+	invalid := customstore.NewRecord("customer")
+	invalid.SetPayload(`{"name":"Ann"}`)
+	if err := store.RecordCreate(ctx, invalid); !errors.As(err, &validationErr) {
+		t.Fatalf("Expected RecordCreate to fail with a *ValidationError, got %v", err)
+	}
+
+	if count, _ := store.RecordCount(ctx, customstore.RecordQuery()); count != 0 {
+		t.Fatalf("Expected the invalid record not to be created, got %d records", count)
+	}
+
+	customer := customstore.NewRecord("customer")
+	customer.SetPayload(`{"name":"Ann","email":"ann@example.com"}`)
+	if err := store.RecordCreate(ctx, customer); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	customer.SetPayload(`{"name":"Ann","email":"ann"}`)
+	if err := store.RecordUpdate(ctx, customer); !errors.As(err, &validationErr) {
+		t.Fatalf("Expected RecordUpdate to fail with a *ValidationError, got %v", err)
+	}
+
+	// changes other than the payload are not validated
+	stored, err := store.RecordFindByID(ctx, customer.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	stored.SetMemo("memo")
+	if err := store.RecordUpdate(ctx, stored); err != nil {
+		t.Fatalf("RecordUpdate of the memo failed: %v", err)
+	}
+
+	// the records of a batch are all validated before any is created
+	valid := customstore.NewRecord("customer")
+	valid.SetPayload(`{"name":"Bob","email":"bob@example.com"}`)
+
+	err = store.RecordCreateMany(ctx, []customstore.RecordInterface{valid, invalid})
+
+	var batchErr *customstore.BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 1 || !errors.As(batchErr.Errors[1], &validationErr) {
+		t.Fatalf("Expected a *BatchError with the validation error of the second record, got %v", err)
+	}
+
+	store.UnregisterSchema("customer")
+
+	if err := store.RecordCreate(ctx, invalid); err != nil {
+		t.Fatalf("Expected RecordCreate to succeed without a schema, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package customstore
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// defaultSubscribeBufferSize is the buffer of a subscription channel,
+// when the buffer size is not set
+const defaultSubscribeBufferSize = 100
+
+// Policies for a subscriber too slow to keep up, whose buffer is full
+const SLOW_CONSUMER_DROP_NEWEST = "drop_newest"
+const SLOW_CONSUMER_DROP_OLDEST = "drop_oldest"
+const SLOW_CONSUMER_UNSUBSCRIBE = "unsubscribe"
+
+// ChangeEvent is a change of a record, sent to the subscribers after the
+// change is committed
+type ChangeEvent struct {
+	// Operation is the type of change, i.e. CHANGE_OPERATION_CREATE
+	Operation string
+
+	// RecordID is the ID of the changed record
+	RecordID string
+
+	// RecordType is the type of the changed record
+	RecordType string
+
+	// Changed are the changed fields, empty for deletes
+	Changed map[string]string
+}
+
+// SubscribeOptions define the changes a subscriber receives, and how it is
+// treated when it does not keep up
+type SubscribeOptions struct {
+	// RecordTypes limits the events to the record types, if any
+	RecordTypes []string
+
+	// RecordIDs limits the events to the records, if any
+	RecordIDs []string
+
+	// BufferSize is the buffer of the channel, 100 if not set
+	BufferSize int
+
+	// SlowConsumerPolicy is what happens to an event when the buffer is
+	// full, SLOW_CONSUMER_DROP_NEWEST if not set. Writers never wait for
+	// a subscriber
+	SlowConsumerPolicy string
+}
+
+// subscription is a subscriber, with its channel
+type subscription struct {
+	opts   SubscribeOptions
+	events chan ChangeEvent
+}
+
+// subscriptionRegistry is the registry of the subscribers
+type subscriptionRegistry struct {
+	mu            sync.Mutex
+	subscriptions map[*subscription]struct{}
+}
+
+// Subscribe returns a channel receiving the changes of the records, after
+// every successful create, update, soft delete, restore and delete. The
+// changes made in a transaction are sent once it is committed, except for
+// an external transaction, whose outcome the store does not know.
+//
+// The cancel function closes the channel, and must be called when the
+// subscriber is done
+func (st *storeImplementation) Subscribe(opts SubscribeOptions) (<-chan ChangeEvent, func()) {
+	if opts.BufferSize < 1 {
+		opts.BufferSize = defaultSubscribeBufferSize
+	}
+
+	if opts.SlowConsumerPolicy == "" {
+		opts.SlowConsumerPolicy = SLOW_CONSUMER_DROP_NEWEST
+	}
+
+	sub := &subscription{
+		opts:   opts,
+		events: make(chan ChangeEvent, opts.BufferSize),
+	}
+
+	st.subscriptions.mu.Lock()
+	st.subscriptions.subscriptions[sub] = struct{}{}
+	st.subscriptions.mu.Unlock()
+
+	cancel := func() {
+		st.subscriptions.mu.Lock()
+		defer st.subscriptions.mu.Unlock()
+
+		st.subscriptions.remove(sub)
+	}
+
+	return sub.events, cancel
+}
+
+// subscribed returns true if there is any subscriber
+func (st *storeImplementation) subscribed() bool {
+	st.subscriptions.mu.Lock()
+	defer st.subscriptions.mu.Unlock()
+
+	return len(st.subscriptions.subscriptions) > 0
+}
+
+// changePublish sends the events to the subscribers, or defers them until
+// the commit, when the store is bound to a transaction it started
+func (st *storeImplementation) changePublish(events []ChangeEvent) {
+	if st.pendingEvents != nil {
+		*st.pendingEvents = append(*st.pendingEvents, events...)
+		return
+	}
+
+	st.subscriptions.publish(events)
+}
+
+// publish sends the events to the matching subscribers, applying their
+// slow consumer policy when their buffer is full
+func (r *subscriptionRegistry) publish(events []ChangeEvent) {
+	if len(events) < 1 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, event := range events {
+		for sub := range r.subscriptions {
+			if !sub.matches(event) {
+				continue
+			}
+
+			event := event
+			event.Changed = maps.Clone(event.Changed)
+
+			select {
+			case sub.events <- event:
+				continue
+			default:
+			}
+
+			switch sub.opts.SlowConsumerPolicy {
+			case SLOW_CONSUMER_DROP_OLDEST:
+				select {
+				case <-sub.events:
+				default:
+				}
+
+				select {
+				case sub.events <- event:
+				default:
+				}
+			case SLOW_CONSUMER_UNSUBSCRIBE:
+				r.remove(sub)
+			}
+		}
+	}
+}
+
+// remove removes the subscriber and closes its channel. It must be called
+// with the lock held
+func (r *subscriptionRegistry) remove(sub *subscription) {
+	if _, exists := r.subscriptions[sub]; !exists {
+		return
+	}
+
+	delete(r.subscriptions, sub)
+	close(sub.events)
+}
+
+// matches returns true if the subscriber receives the event
+func (sub *subscription) matches(event ChangeEvent) bool {
+	if len(sub.opts.RecordTypes) > 0 && !slices.Contains(sub.opts.RecordTypes, event.RecordType) {
+		return false
+	}
+
+	if len(sub.opts.RecordIDs) > 0 && !slices.Contains(sub.opts.RecordIDs, event.RecordID) {
+		return false
+	}
+
+	return true
+}
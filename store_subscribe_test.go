@@ -0,0 +1,208 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestStoreSubscribe(t *testing.T) {
+	db := InitDB("test_data_store_subscribe.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_subscribe",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	events, cancel := store.Subscribe(customstore.SubscribeOptions{
+		RecordTypes: []string{"person"},
+	})
+	defer cancel()
+
+	person := customstore.NewRecord("person")
+	if err := store.RecordCreate(ctx, person); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	// not subscribed to the type
+	if err := store.RecordCreate(ctx, customstore.NewRecord("task")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	person.SetMemo("updated")
+	if err := store.RecordUpdate(ctx, person); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	if err := store.RecordSoftDelete(ctx, person); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+
+	if err := store.RecordRestoreByID(ctx, person.ID()); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+
+	// a rolled back transaction sends nothing
+	errRollback := errors.New("rollback")
+	err = store.WithTx(ctx, func(tx customstore.StoreInterface) error {
+		if err := tx.RecordCreate(ctx, customstore.NewRecord("person")); err != nil {
+			return err
+		}
+		return errRollback
+	})
+
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Expected the transaction to be rolled back, got %v", err)
+	}
+
+	if err := store.RecordDeleteByID(ctx, person.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+
+	expected := []string{
+		customstore.CHANGE_OPERATION_CREATE,
+		customstore.CHANGE_OPERATION_UPDATE,
+		customstore.CHANGE_OPERATION_SOFT_DELETE,
+		customstore.CHANGE_OPERATION_RESTORE,
+		customstore.CHANGE_OPERATION_DELETE,
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+
+	for index, operation := range expected {
+		event := <-events
+
+		if event.Operation != operation || event.RecordID != person.ID() || event.RecordType != "person" {
+			t.Fatalf("Expected event #%d to be %s of %s, got %+v", index, operation, person.ID(), event)
+		}
+	}
+
+	cancel()
+
+	if _, open := <-events; open {
+		t.Fatal("Expected the channel to be closed when cancelled")
+	}
+
+	// cancelling twice is harmless
+	cancel()
+}
+
+func TestStoreSubscribeTransaction(t *testing.T) {
+	db := InitDB("test_data_store_subscribe_tx.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_subscribe_tx",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	person := customstore.NewRecord("person")
+
+	events, cancel := store.Subscribe(customstore.SubscribeOptions{
+		RecordIDs: []string{person.ID()},
+	})
+	defer cancel()
+
+	err = store.WithTx(ctx, func(tx customstore.StoreInterface) error {
+		if err := tx.RecordCreate(ctx, person); err != nil {
+			return err
+		}
+
+		if err := tx.RecordCreate(ctx, customstore.NewRecord("person")); err != nil {
+			return err
+		}
+
+		if len(events) != 0 {
+			t.Fatalf("Expected no events before the commit, got %d", len(events))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event after the commit, got %d", len(events))
+	}
+}
+
+func TestStoreSubscribeSlowConsumer(t *testing.T) {
+	db := InitDB("test_data_store_subscribe_slow.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_store_subscribe_slow",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	newest, cancelNewest := store.Subscribe(customstore.SubscribeOptions{
+		BufferSize: 2,
+	})
+	defer cancelNewest()
+
+	oldest, cancelOldest := store.Subscribe(customstore.SubscribeOptions{
+		BufferSize:         2,
+		SlowConsumerPolicy: customstore.SLOW_CONSUMER_DROP_OLDEST,
+	})
+	defer cancelOldest()
+
+	unsubscribed, cancelUnsubscribed := store.Subscribe(customstore.SubscribeOptions{
+		BufferSize:         2,
+		SlowConsumerPolicy: customstore.SLOW_CONSUMER_UNSUBSCRIBE,
+	})
+	defer cancelUnsubscribed()
+
+	records := []customstore.RecordInterface{}
+	for range 3 {
+		record := customstore.NewRecord("person")
+		if err := store.RecordCreate(ctx, record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	if event := <-newest; event.RecordID != records[0].ID() {
+		t.Fatalf("Expected the newest event to be dropped, got %s first", event.RecordID)
+	}
+
+	if event := <-oldest; event.RecordID != records[1].ID() {
+		t.Fatalf("Expected the oldest event to be dropped, got %s first", event.RecordID)
+	}
+
+	count := 0
+	for range unsubscribed {
+		count++
+	}
+
+	if count != 2 {
+		t.Fatalf("Expected the slow subscriber to be unsubscribed after 2 events, got %d", count)
+	}
+}
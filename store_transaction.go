@@ -0,0 +1,146 @@
+package customstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/gouniverse/base/database"
+	"github.com/gouniverse/sb"
+)
+
+// WithTx runs the callback inside a database transaction. The store passed
+// to the callback is bound to the transaction. The transaction is committed
+// when the callback returns nil, and rolled back when it returns an error
+// or panics.
+//
+// If the store (or the context) is already bound to a transaction,
+// the callback joins it instead of starting a new one.
+func (st *storeImplementation) WithTx(ctx context.Context, fn func(tx StoreInterface) error) error {
+	return st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		return fn(txStore)
+	})
+}
+
+// WithExternalTx returns a store bound to an existing transaction, which
+// was started outside of the store. Committing or rolling back the
+// transaction remains the responsibility of the caller.
+func (st *storeImplementation) WithExternalTx(tx *sql.Tx) StoreInterface {
+	return st.withTx(tx)
+}
+
+// executeInTransaction runs the callback with a store bound to a transaction,
+// starting a new one only if there is no transaction to join
+func (st *storeImplementation) executeInTransaction(ctx context.Context, fn func(txStore *storeImplementation) error) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if st.tx != nil {
+		return fn(st)
+	}
+
+	if database.IsQueryableContext(ctx) {
+		qctx := ctx.(database.QueryableContext)
+		if qctx.IsTx() {
+			return fn(st.withTx(qctx.Queryable().(*sql.Tx)))
+		}
+	}
+
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	tx, err := st.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	txStore := st.withTx(tx)
+	txStore.pendingEvents = &[]ChangeEvent{}
+
+	if err := fn(txStore); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			return errors.Join(err, errRollback)
+		}
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	st.subscriptions.publish(*txStore.pendingEvents)
+
+	return nil
+}
+
+// executeWrite runs a write with a store bound to a transaction, when the
+// store writes alongside it (i.e. the history or the change feed) and all
+// must be atomic.
+// Otherwise the write runs with the store as is
+func (st *storeImplementation) executeWrite(ctx context.Context, fn func(txStore *storeImplementation) error) error {
+	if !st.historyEnabled && !st.changesEnabled {
+		return fn(st)
+	}
+
+	return st.executeInTransaction(ctx, fn)
+}
+
+// withTx returns a shallow copy of the store bound to the transaction
+func (st *storeImplementation) withTx(tx *sql.Tx) *storeImplementation {
+	txStore := *st
+	txStore.tx = tx
+	txStore.pendingEvents = nil
+	return &txStore
+}
+
+// savepointName is the name of the savepoints set by the store
+const savepointName = "customstore_savepoint"
+
+// savepointRun runs the callback after a savepoint, rolling back to it if
+// the callback fails, so the transaction can go on. The store must be
+// bound to a transaction
+func (st *storeImplementation) savepointRun(ctx context.Context, fn func() error) error {
+	setSql := "SAVEPOINT " + savepointName
+	rollbackSql := "ROLLBACK TO SAVEPOINT " + savepointName
+	releaseSql := "RELEASE SAVEPOINT " + savepointName
+
+	if st.dbDriverName == sb.DIALECT_MSSQL {
+		setSql = "SAVE TRANSACTION " + savepointName
+		rollbackSql = "ROLLBACK TRANSACTION " + savepointName
+		releaseSql = "" // savepoints are released with the transaction
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	if _, err := database.Execute(qctx, setSql); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, errRollback := database.Execute(qctx, rollbackSql); errRollback != nil {
+			return errors.Join(err, errRollback)
+		}
+
+		return err
+	}
+
+	if releaseSql == "" {
+		return nil
+	}
+
+	_, err := database.Execute(qctx, releaseSql)
+
+	return err
+}
@@ -0,0 +1,178 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestWithTxCommit(t *testing.T) {
+	db := InitDB("test_data_store_with_tx_commit.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_with_tx_commit",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	existing := customstore.NewRecord("person")
+	err = store.RecordCreate(context.Background(), existing)
+	if err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	created := customstore.NewRecord("person")
+
+	err = store.WithTx(context.Background(), func(tx customstore.StoreInterface) error {
+		if err := tx.RecordCreate(context.Background(), created); err != nil {
+			return err
+		}
+
+		return tx.RecordSoftDeleteByID(context.Background(), existing.ID())
+	})
+
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(context.Background(), created.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found == nil {
+		t.Fatalf("Expected record created in transaction to be committed, but it was not found")
+	}
+
+	found, err = store.RecordFindByID(context.Background(), existing.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected record soft deleted in transaction to be committed, but it was found")
+	}
+}
+
+func TestWithTxRollback(t *testing.T) {
+	db := InitDB("test_data_store_with_tx_rollback.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_with_tx_rollback",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// Rollback on error
+	errExpected := errors.New("expected error")
+	record := customstore.NewRecord("person")
+
+	err = store.WithTx(context.Background(), func(tx customstore.StoreInterface) error {
+		if err := tx.RecordCreate(context.Background(), record); err != nil {
+			return err
+		}
+		return errExpected
+	})
+
+	if !errors.Is(err, errExpected) {
+		t.Fatalf("Expected error %v, but got %v", errExpected, err)
+	}
+
+	found, err := store.RecordFindByID(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected record to be rolled back after error, but it was found")
+	}
+
+	// Rollback on panic
+	recordPanic := customstore.NewRecord("person")
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic to be propagated, but it was not")
+			}
+		}()
+
+		_ = store.WithTx(context.Background(), func(tx customstore.StoreInterface) error {
+			if err := tx.RecordCreate(context.Background(), recordPanic); err != nil {
+				return err
+			}
+			panic("expected panic")
+		})
+	}()
+
+	found, err = store.RecordFindByID(context.Background(), recordPanic.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected record to be rolled back after panic, but it was found")
+	}
+}
+
+func TestWithExternalTx(t *testing.T) {
+	db := InitDB("test_data_store_with_external_tx.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_with_external_tx",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+
+	record := customstore.NewRecord("person")
+	err = store.WithExternalTx(tx).RecordCreate(context.Background(), record)
+	if err != nil {
+		t.Fatalf("RecordCreate in external transaction failed: %v", err)
+	}
+
+	// The external transaction is joined, not committed, by WithTx
+	err = store.WithExternalTx(tx).WithTx(context.Background(), func(txStore customstore.StoreInterface) error {
+		found, err := txStore.RecordFindByID(context.Background(), record.ID())
+		if err != nil {
+			return err
+		}
+		if found == nil {
+			return errors.New("record not visible inside the joined transaction")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("WithTx joining external transaction failed: %v", err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback of external transaction failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected record to be rolled back with the external transaction, but it was found")
+	}
+}
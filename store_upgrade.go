@@ -0,0 +1,94 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+)
+
+// upgradeBatchSize is the number of records upgraded per batch
+const upgradeBatchSize = 1000
+
+// recordFromExistingData creates a record from its stored columns. With
+// payload versioning, the payload is migrated to the latest version of its
+// type. A record which fails to migrate is left as stored, and the error is
+// logged, UpgradeAll reports it
+func (st *storeImplementation) recordFromExistingData(data map[string]string) RecordInterface {
+	record := NewRecordFromExistingData(data)
+
+	if !st.payloadVersioningEnabled {
+		return record
+	}
+
+	if _, err := migratePayload(record); err != nil {
+		st.logger.Warn("Payload migration failed",
+			"record_id", record.ID(),
+			"record_type", record.Type(),
+			"payload_version", record.PayloadVersion(),
+			"error", err)
+	}
+
+	return record
+}
+
+// UpgradeAll migrates the payloads of all the records of the type, soft
+// deleted and expired ones included, to the latest version, and stores
+// them in batches. It returns the number of records upgraded
+func (st *storeImplementation) UpgradeAll(ctx context.Context, recordType string) (int64, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if !st.payloadVersioningEnabled {
+		return 0, errors.New("payload versioning is not enabled")
+	}
+
+	if recordType == "" {
+		return 0, errors.New("record type is empty")
+	}
+
+	latest := LatestPayloadVersion(recordType)
+
+	var upgraded int64
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return upgraded, err
+		}
+
+		records, err := st.RecordList(ctx, RecordQuery().
+			SetType(recordType).
+			SetSoftDeletedIncluded(true).
+			SetExpiredIncluded(true).
+			Where(Or(
+				ColumnFilter(COLUMN_PAYLOAD_VERSION, "IS NULL", nil),
+				ColumnFilter(COLUMN_PAYLOAD_VERSION, "<", latest),
+			)).
+			SetOrderBy(COLUMN_ID).
+			SetLimit(upgradeBatchSize))
+
+		if err != nil {
+			return upgraded, err
+		}
+
+		for _, record := range records {
+			// the records are migrated when read, unless the migration failed
+			if record.PayloadVersion() >= latest {
+				continue
+			}
+
+			if _, err := migratePayload(record); err != nil {
+				return upgraded, errors.Join(errors.New("record "+record.ID()+" could not be upgraded"), err)
+			}
+		}
+
+		if err := st.RecordUpdateMany(ctx, records); err != nil {
+			return upgraded, err
+		}
+
+		upgraded += int64(len(records))
+
+		if len(records) < upgradeBatchSize {
+			return upgraded, nil
+		}
+	}
+}
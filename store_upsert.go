@@ -0,0 +1,294 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"maps"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/base/database"
+	"github.com/spf13/cast"
+)
+
+// RecordUpsert inserts the record, or updates it if a record with the
+// same ID already exists (including a soft deleted one). The original
+// created_at of an existing record is preserved.
+//
+// Returns true if the record was inserted, false if it was updated. The
+// insert decides it: of concurrent upserts of the same new ID, one inserts
+// the record, and the others wait for it and update it, running the update
+// hooks and logging updates.
+func (st *storeImplementation) RecordUpsert(ctx context.Context, record RecordInterface) (inserted bool, err error) {
+	if st.db == nil {
+		return false, errors.New("database is not initialized")
+	}
+
+	if record == nil {
+		return false, errors.New("record is nil")
+	}
+
+	if record.ID() == "" {
+		return false, errors.New("record ID is required")
+	}
+
+	err = st.executeInTransaction(ctx, func(txStore *storeImplementation) error {
+		now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+		record.SetCreatedAt(now)
+		record.SetUpdatedAt(now)
+
+		if st.versioningEnabled {
+			record.SetVersion(1)
+		}
+
+		if st.payloadVersioningEnabled && record.PayloadVersion() < 1 {
+			record.SetPayloadVersion(LatestPayloadVersion(record.Type()))
+		}
+
+		if err := st.recordColumnsCheck(record.Data()); err != nil {
+			return err
+		}
+
+		claimed, err := txStore.recordClaim(ctx, record)
+
+		if err != nil {
+			return err
+		}
+
+		inserted = claimed
+
+		if claimed {
+			return txStore.recordUpsertInserted(ctx, record)
+		}
+
+		return txStore.recordUpsertExisting(ctx, record)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	changed := maps.Clone(record.DataChanged())
+
+	record.MarkAsNotDirty()
+
+	if inserted {
+		err = st.hookRun(ctx, hookAfterCreate, record, changed)
+	} else {
+		err = st.hookRun(ctx, hookAfterUpdate, record, changed)
+	}
+
+	return inserted, err
+}
+
+// recordClaim inserts the record, unless a record with its ID exists, and
+// returns true if it was inserted. A concurrent insert of the same ID waits
+// for the transaction of this one, and is then skipped.
+func (st *storeImplementation) recordClaim(ctx context.Context, record RecordInterface) (bool, error) {
+	// rendered as INSERT IGNORE by the MySQL dialect of goqu
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		Insert(st.tableName).
+		Prepared(true).
+		Rows(record.Data()).
+		OnConflict(goqu.DoNothing()).
+		ToSQL()
+
+	if err != nil {
+		return false, err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Record upsert insert query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	result, err := database.Execute(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// recordUpsertInserted completes the upsert of a record inserted by
+// recordClaim, storing the changes of the before create hooks
+func (st *storeImplementation) recordUpsertInserted(ctx context.Context, record RecordInterface) error {
+	if err := st.hookRun(ctx, hookBeforeCreate, record, record.DataChanged()); err != nil {
+		return err
+	}
+
+	if err := st.ValidatePayload(record.Type(), record.Payload()); err != nil {
+		return err
+	}
+
+	data := record.Data()
+
+	if err := st.recordColumnsCheck(data); err != nil {
+		return err
+	}
+
+	fields := goqu.Record{}
+	for column, value := range data {
+		if column != COLUMN_ID {
+			fields[column] = value
+		}
+	}
+
+	if err := st.recordUpsertWrite(ctx, record.ID(), fields); err != nil {
+		return err
+	}
+
+	return st.changeLog(ctx, CHANGE_OPERATION_CREATE, changeEntry{
+		recordID:   record.ID(),
+		recordType: record.Type(),
+		changed:    record.DataChanged(),
+	})
+}
+
+// recordUpsertExisting completes the upsert of a record which exists, by
+// updating it. The existing row is locked until the transaction ends, where
+// the database supports it.
+func (st *storeImplementation) recordUpsertExisting(ctx context.Context, record RecordInterface) error {
+	existing, exists, err := st.recordExistingForUpdate(ctx, record.ID())
+
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return errors.New("record " + record.ID() + " was deleted during the upsert")
+	}
+
+	record.SetCreatedAt(carbon.Parse(existing[COLUMN_CREATED_AT], carbon.UTC).ToDateTimeString(carbon.UTC))
+
+	if err := st.hookRun(ctx, hookBeforeUpdate, record, record.DataChanged()); err != nil {
+		return err
+	}
+
+	if err := st.ValidatePayload(record.Type(), record.Payload()); err != nil {
+		return err
+	}
+
+	data := record.Data()
+
+	if err := st.recordColumnsCheck(data); err != nil {
+		return err
+	}
+
+	fields := goqu.Record{}
+	for column, value := range data {
+		if column == COLUMN_ID || column == COLUMN_CREATED_AT || column == COLUMN_VERSION {
+			continue // the key and the original creation time are kept, the version is incremented below
+		}
+		fields[column] = value
+	}
+
+	if st.versioningEnabled {
+		fields[COLUMN_VERSION] = goqu.L("? + 1", goqu.I(COLUMN_VERSION))
+	}
+
+	if err := st.historySnapshot(ctx, HISTORY_OPERATION_UPDATE, goqu.C(COLUMN_ID).Eq(record.ID())); err != nil {
+		return err
+	}
+
+	if err := st.recordUpsertWrite(ctx, record.ID(), fields); err != nil {
+		return err
+	}
+
+	if st.versioningEnabled {
+		record.SetVersion(cast.ToInt(existing[COLUMN_VERSION]) + 1)
+	}
+
+	return st.changeLog(ctx, CHANGE_OPERATION_UPDATE, changeEntry{
+		recordID:   record.ID(),
+		recordType: record.Type(),
+		changed:    record.DataChanged(),
+	})
+}
+
+// recordUpsertWrite updates the fields of the record with the given ID
+func (st *storeImplementation) recordUpsertWrite(ctx context.Context, id string, fields goqu.Record) error {
+	sqlStr, sqlParams, err := goqu.Dialect(st.dbDriverName).
+		Update(st.tableName).
+		Prepared(true).
+		Set(fields).
+		Where(goqu.C(COLUMN_ID).Eq(id)).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Record upsert update query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	_, err = database.Execute(qctx, sqlStr, sqlParams...)
+
+	return err
+}
+
+// recordExisting returns the stored columns of the record with the given ID,
+// including soft deleted records, and whether the record exists
+func (st *storeImplementation) recordExisting(ctx context.Context, id string) (data map[string]string, exists bool, err error) {
+	return st.recordSelectByID(ctx, id, false)
+}
+
+// recordExistingForUpdate is recordExisting, locking the row until the
+// transaction ends on the databases supporting SELECT ... FOR UPDATE
+func (st *storeImplementation) recordExistingForUpdate(ctx context.Context, id string) (data map[string]string, exists bool, err error) {
+	return st.recordSelectByID(ctx, id, isDriverPostgres(st.dbDriverName) || isDriverMysql(st.dbDriverName))
+}
+
+// recordSelectByID returns the stored columns of the record with the given
+// ID, and whether the record exists
+func (st *storeImplementation) recordSelectByID(ctx context.Context, id string, forUpdate bool) (data map[string]string, exists bool, err error) {
+	query := goqu.Dialect(st.dbDriverName).
+		From(st.tableName).
+		Prepared(true).
+		Where(goqu.C(COLUMN_ID).Eq(id)).
+		Limit(1)
+
+	if forUpdate {
+		query = query.ForUpdate(exp.Wait)
+	}
+
+	sqlStr, sqlParams, err := query.ToSQL()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if st.debugEnabled {
+		st.logger.Debug("Record existing query", "query", sqlStr, "params", sqlParams)
+	}
+
+	qctx, cancel := st.toQuerableContext(ctx)
+	defer cancel()
+
+	mapped, err := database.SelectToMapString(qctx, sqlStr, sqlParams...)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(mapped) < 1 {
+		return nil, false, nil
+	}
+
+	return mapped[0], true, nil
+}
@@ -0,0 +1,186 @@
+package customstore_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordUpsert(t *testing.T) {
+	db := InitDB("test_data_store_record_upsert.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_upsert",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person")
+	record.SetCreatedAt("2020-01-01 00:00:00")
+	record.SetMemo("first")
+
+	inserted, err := store.RecordUpsert(context.Background(), record)
+	if err != nil {
+		t.Fatalf("RecordUpsert (insert) failed: %v", err)
+	}
+	if !inserted {
+		t.Fatalf("Expected the record to be inserted, but it was updated")
+	}
+
+	found, err := store.RecordFindByID(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found == nil {
+		t.Fatalf("Expected record to be found after upsert, but got nil")
+	}
+
+	createdAt := found.CreatedAtCarbon().ToDateTimeString()
+
+	// A fresh instance with the same ID, as synced from an external system
+	synced := customstore.NewRecord("person")
+	synced.SetID(record.ID())
+	synced.SetMemo("second")
+
+	inserted, err = store.RecordUpsert(context.Background(), synced)
+	if err != nil {
+		t.Fatalf("RecordUpsert (update) failed: %v", err)
+	}
+	if inserted {
+		t.Fatalf("Expected the record to be updated, but it was inserted")
+	}
+
+	found, err = store.RecordFindByID(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	if found.Memo() != "second" {
+		t.Fatalf("Expected memo %q, but got %q", "second", found.Memo())
+	}
+
+	if found.CreatedAtCarbon().ToDateTimeString() != createdAt {
+		t.Fatalf("Expected created_at %q to be preserved, but got %q", createdAt, found.CreatedAtCarbon().ToDateTimeString())
+	}
+
+	count, err := store.RecordCount(context.Background(), customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected count 1, but got %d", count)
+	}
+}
+
+func TestRecordUpsertConcurrent(t *testing.T) {
+	os.Remove("test_data_store_record_upsert_concurrent.db")
+	db, err := sql.Open("sqlite3", "test_data_store_record_upsert_concurrent.db?parseTime=true&_busy_timeout=10000")
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_upsert_concurrent",
+		AutomigrateEnabled: true,
+		ChangesEnabled:     true,
+		VersioningEnabled:  true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	var beforeCreates, afterCreates, afterUpdates atomic.Int64
+	store.OnBeforeCreate(func(ctx context.Context, record customstore.RecordInterface, changed map[string]string) error {
+		beforeCreates.Add(1)
+		return nil
+	})
+	store.OnAfterCreate(func(ctx context.Context, record customstore.RecordInterface, changed map[string]string) error {
+		afterCreates.Add(1)
+		return nil
+	})
+	store.OnAfterUpdate(func(ctx context.Context, record customstore.RecordInterface, changed map[string]string) error {
+		afterUpdates.Add(1)
+		return nil
+	})
+
+	const writers = 5
+
+	var wg sync.WaitGroup
+	var insertedCount atomic.Int64
+	errs := make(chan error, writers)
+
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// the same new ID, as synced from an external system
+			record := customstore.NewRecord("person")
+			record.SetID("external-1")
+
+			inserted, err := store.RecordUpsert(ctx, record)
+			if inserted {
+				insertedCount.Add(1)
+			}
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("RecordUpsert failed: %v", err)
+		}
+	}
+
+	if insertedCount.Load() != 1 {
+		t.Fatalf("Expected exactly one upsert to insert, got %d", insertedCount.Load())
+	}
+
+	if beforeCreates.Load() != 1 || afterCreates.Load() != 1 || afterUpdates.Load() != writers-1 {
+		t.Fatalf("Expected the create hooks to run once and the update hooks %d times, got %d, %d and %d",
+			writers-1, beforeCreates.Load(), afterCreates.Load(), afterUpdates.Load())
+	}
+
+	changes, err := store.ChangesSince(ctx, 0, 100)
+	if err != nil {
+		t.Fatalf("ChangesSince failed: %v", err)
+	}
+
+	creates := 0
+	for _, change := range changes {
+		if change.Operation == customstore.CHANGE_OPERATION_CREATE {
+			creates++
+		}
+	}
+
+	if len(changes) != writers || creates != 1 {
+		t.Fatalf("Expected %d changes with one create, got %d with %d creates", writers, len(changes), creates)
+	}
+
+	found, err := store.RecordFindByID(ctx, "external-1")
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	if found.Version() != writers {
+		t.Fatalf("Expected the record at version %d, got %d", writers, found.Version())
+	}
+}
@@ -0,0 +1,88 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+func TestRecordUpdateVersionConflict(t *testing.T) {
+	db := InitDB("test_data_store_record_update_version_conflict.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_update_version_conflict",
+		AutomigrateEnabled: true,
+		VersioningEnabled:  true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person")
+	if err := store.RecordCreate(context.Background(), record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if record.Version() != 1 {
+		t.Fatalf("Expected version 1 after create, but got %d", record.Version())
+	}
+
+	// Two workers load the same record
+	workerA, err := store.RecordFindByID(context.Background(), record.ID())
+	if err != nil || workerA == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	workerB, err := store.RecordFindByID(context.Background(), record.ID())
+	if err != nil || workerB == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	workerA.SetMemo("from A")
+	if err := store.RecordUpdate(context.Background(), workerA); err != nil {
+		t.Fatalf("RecordUpdate by worker A failed: %v", err)
+	}
+
+	if workerA.Version() != 2 {
+		t.Fatalf("Expected version 2 after update, but got %d", workerA.Version())
+	}
+
+	workerB.SetMemo("from B")
+	err = store.RecordUpdate(context.Background(), workerB)
+
+	if !errors.Is(err, customstore.ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict, but got %v", err)
+	}
+
+	found, err := store.RecordFindByID(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	if found.Memo() != "from A" {
+		t.Fatalf("Expected memo %q, but got %q", "from A", found.Memo())
+	}
+
+	if found.Version() != 2 {
+		t.Fatalf("Expected stored version 2, but got %d", found.Version())
+	}
+
+	// Upsert overwrites and bumps the version
+	if _, err := store.RecordUpsert(context.Background(), found); err != nil {
+		t.Fatalf("RecordUpsert failed: %v", err)
+	}
+
+	found, err = store.RecordFindByID(context.Background(), record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	if found.Version() != 3 {
+		t.Fatalf("Expected stored version 3 after upsert, but got %d", found.Version())
+	}
+}
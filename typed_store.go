@@ -0,0 +1,203 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrRecordNotFound is returned by the typed store, when the record
+// does not exist
+var ErrRecordNotFound = errors.New("record not found")
+
+// RecordMeta are the columns of a record, apart from its payload
+type RecordMeta struct {
+	ID            string
+	Type          string
+	Memo          string
+	Metas         map[string]string
+	CreatedAt     string
+	UpdatedAt     string
+	SoftDeletedAt string
+	ExpiresAt     string
+	Version       int
+}
+
+// TypedRecord is a record with its payload unmarshalled to T
+type TypedRecord[T any] struct {
+	Meta RecordMeta
+	Data T
+}
+
+// TypedStore stores the records of a single type, marshalling their
+// payload from and to T with encoding/json, so the struct tags apply
+type TypedStore[T any] struct {
+	store      StoreInterface
+	recordType string
+}
+
+// NewTypedStore creates a typed store for the record type, over the store
+func NewTypedStore[T any](store StoreInterface, recordType string) (*TypedStore[T], error) {
+	if store == nil {
+		return nil, errors.New("customstore typed store: store is required")
+	}
+
+	if recordType == "" {
+		return nil, errors.New("customstore typed store: recordType is required")
+	}
+
+	return &TypedStore[T]{
+		store:      store,
+		recordType: recordType,
+	}, nil
+}
+
+// Create creates a record with the data as its payload
+func (ts *TypedStore[T]) Create(ctx context.Context, data T) (RecordMeta, error) {
+	record := NewRecord(ts.recordType)
+
+	if err := setTypedPayload(record, data); err != nil {
+		return RecordMeta{}, err
+	}
+
+	if err := ts.store.RecordCreate(ctx, record); err != nil {
+		return RecordMeta{}, err
+	}
+
+	return toRecordMeta(record)
+}
+
+// Get returns the data and the meta of the record with the ID, or
+// ErrRecordNotFound if there is no such record of the type
+func (ts *TypedStore[T]) Get(ctx context.Context, id string) (T, RecordMeta, error) {
+	var data T
+
+	list, err := ts.List(ctx, RecordQuery().SetID(id).SetLimit(1))
+
+	if err != nil {
+		return data, RecordMeta{}, err
+	}
+
+	if len(list) < 1 {
+		return data, RecordMeta{}, ErrRecordNotFound
+	}
+
+	return list[0].Data, list[0].Meta, nil
+}
+
+// List returns the records matching the query, limited to the record type
+func (ts *TypedStore[T]) List(ctx context.Context, query RecordQueryInterface) ([]TypedRecord[T], error) {
+	if query == nil {
+		query = RecordQuery()
+	}
+
+	records, err := ts.store.RecordList(ctx, query.SetType(ts.recordType))
+
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]TypedRecord[T], 0, len(records))
+	for _, record := range records {
+		typed, err := toTypedRecord[T](record)
+
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, typed)
+	}
+
+	return list, nil
+}
+
+// Update replaces the payload of the record with the ID of the meta by the
+// data. When versioning is enabled and the meta has a version, the update
+// fails with ErrVersionConflict if the record was modified since
+func (ts *TypedStore[T]) Update(ctx context.Context, meta RecordMeta, data T) (RecordMeta, error) {
+	if meta.ID == "" {
+		return RecordMeta{}, errors.New("record id is required")
+	}
+
+	list, err := ts.store.RecordList(ctx, RecordQuery().
+		SetID(meta.ID).
+		SetType(ts.recordType).
+		SetLimit(1))
+
+	if err != nil {
+		return RecordMeta{}, err
+	}
+
+	if len(list) < 1 {
+		return RecordMeta{}, ErrRecordNotFound
+	}
+
+	record := list[0]
+
+	if meta.Version > 0 {
+		record.SetVersion(meta.Version)
+	}
+
+	if err := setTypedPayload(record, data); err != nil {
+		return RecordMeta{}, err
+	}
+
+	if err := ts.store.RecordUpdate(ctx, record); err != nil {
+		return RecordMeta{}, err
+	}
+
+	return toRecordMeta(record)
+}
+
+// setTypedPayload marshals the data as the payload of the record
+func setTypedPayload[T any](record RecordInterface, data T) error {
+	payload, err := json.Marshal(data)
+
+	if err != nil {
+		return err
+	}
+
+	record.SetPayload(string(payload))
+
+	return nil
+}
+
+// toTypedRecord unmarshals the payload of the record
+func toTypedRecord[T any](record RecordInterface) (TypedRecord[T], error) {
+	var data T
+
+	if record.Payload() != "" {
+		if err := json.Unmarshal([]byte(record.Payload()), &data); err != nil {
+			return TypedRecord[T]{}, err
+		}
+	}
+
+	meta, err := toRecordMeta(record)
+
+	if err != nil {
+		return TypedRecord[T]{}, err
+	}
+
+	return TypedRecord[T]{Meta: meta, Data: data}, nil
+}
+
+// toRecordMeta returns the meta of the record
+func toRecordMeta(record RecordInterface) (RecordMeta, error) {
+	metas, err := record.Metas()
+
+	if err != nil {
+		return RecordMeta{}, err
+	}
+
+	return RecordMeta{
+		ID:            record.ID(),
+		Type:          record.Type(),
+		Memo:          record.Memo(),
+		Metas:         metas,
+		CreatedAt:     record.CreatedAt(),
+		UpdatedAt:     record.UpdatedAt(),
+		SoftDeletedAt: record.SoftDeletedAt(),
+		ExpiresAt:     record.ExpiresAt(),
+		Version:       record.Version(),
+	}, nil
+}
@@ -0,0 +1,117 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gouniverse/customstore"
+)
+
+type typedStoreCustomer struct {
+	Name  string   `json:"name"`
+	Email string   `json:"email,omitempty"`
+	Age   int      `json:"age"`
+	Tags  []string `json:"tags"`
+}
+
+func TestTypedStore(t *testing.T) {
+	db := InitDB("test_data_typed_store.db")
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_typed_store",
+		AutomigrateEnabled: true,
+		VersioningEnabled:  true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	customers, err := customstore.NewTypedStore[typedStoreCustomer](store, "customer")
+	if err != nil {
+		t.Fatalf("NewTypedStore failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	meta, err := customers.Create(ctx, typedStoreCustomer{Name: "Ann", Age: 30, Tags: []string{"vip"}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if meta.ID == "" || meta.Type != "customer" || meta.Version != 1 {
+		t.Fatalf("Expected the meta of the created record, got %+v", meta)
+	}
+
+	stored, err := store.RecordFindByID(ctx, meta.ID)
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	if stored.Payload() != `{"name":"Ann","age":30,"tags":["vip"]}` {
+		t.Fatalf("Expected the payload to follow the struct tags, got %s", stored.Payload())
+	}
+
+	// a record of another type is out of reach
+	other := customstore.NewRecord("supplier")
+	other.SetPayload(`{"name":"Bob"}`)
+	if err := store.RecordCreate(ctx, other); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, _, err := customers.Get(ctx, other.ID()); !errors.Is(err, customstore.ErrRecordNotFound) {
+		t.Fatalf("Expected ErrRecordNotFound for a record of another type, got %v", err)
+	}
+
+	customer, meta, err := customers.Get(ctx, meta.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	if customer.Name != "Ann" || customer.Age != 30 || len(customer.Tags) != 1 {
+		t.Fatalf("Expected the customer to be unmarshalled, got %+v", customer)
+	}
+
+	customer.Age = 31
+	updatedMeta, err := customers.Update(ctx, meta, customer)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if updatedMeta.Version != 2 {
+		t.Fatalf("Expected version 2, got %d", updatedMeta.Version)
+	}
+
+	// the meta loaded before the update is stale now
+	if _, err := customers.Update(ctx, meta, customer); !errors.Is(err, customstore.ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict, got %v", err)
+	}
+
+	if _, err := customers.Create(ctx, typedStoreCustomer{Name: "Cid", Age: 40}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	list, err := customers.List(ctx, customstore.RecordQuery().
+		SetOrderBy(customstore.COLUMN_CREATED_AT).
+		SetSortOrder("asc"))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	if len(list) != 2 {
+		t.Fatalf("Expected only the 2 customers to be listed, got %d", len(list))
+	}
+
+	for _, item := range list {
+		if item.Meta.Type != "customer" || item.Data.Name == "" {
+			t.Fatalf("Expected a customer, got %+v", item)
+		}
+	}
+
+	if _, err := customstore.NewTypedStore[typedStoreCustomer](store, ""); err == nil {
+		t.Fatal("Expected NewTypedStore to require the record type")
+	}
+}